@@ -44,6 +44,11 @@ type ValidateResponse struct {
 	City           null.String `json:"city"`
 	Zipcode        null.String `json:"zipcode"`
 	RawProcessedAt string      `json:"processed_at"`
+
+	// SubmittedAddress is the address as passed to Validate or ValidateBatch,
+	// which can differ from `Address` (eg: internationalized domains are sent
+	// in punycode); it is set by the SDK and not part of the API payload
+	SubmittedAddress string `json:"-"`
 }
 
 func (v ValidateResponse) ProcessedAt() (time.Time, error) {
@@ -149,6 +154,40 @@ func Validate(email string, IPAddress string) (*ValidateResponse, error) {
 }
 
 func ValidateWithTimeout(email string, IPAddress string, timeout string) (*ValidateResponse, error) {
+	submitted_email := email
+
+	// Spare a credit for malformed addresses, if requested
+	if SYNTAX_PRECHECK {
+		precheck := PrecheckEmail(email)
+		if !precheck.Valid {
+			return precheck.Response(), nil
+		}
+		email = precheck.Normalized
+	}
+	var suggestion null.String
+	if TYPO_PRESCREEN {
+		suggestion = SuggestEmail(email)
+	}
+
+	email = apiAddress(email)
+
+	// Serve repeated validations from the cache, if one is configured
+	if cached_response, ok := cachedValidateResponse(email, IPAddress); ok {
+		cached_response.SubmittedAddress = submitted_email
+		applyTypoSuggestion(cached_response, suggestion)
+		return cached_response, nil
+	}
+
+	response, error_ := coalescedValidateRequest(email, IPAddress, timeout)
+	if response != nil {
+		response.SubmittedAddress = submitted_email
+		applyTypoSuggestion(response, suggestion)
+	}
+	return response, error_
+}
+
+// validateRequest - perform the actual /validate request
+func validateRequest(email string, IPAddress string, timeout string) (*ValidateResponse, error) {
 	// Prepare the parameters
 	params := url.Values{}
 	params.Set("email", email)
@@ -0,0 +1,142 @@
+package zerobouncego
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// BATCH_VALIDATE_MAX_EMAILS - maximum number of emails accepted by one
+	// /validatebatch request
+	BATCH_VALIDATE_MAX_EMAILS = 200
+	// BATCH_VALIDATE_REQUESTS_PER_MINUTE - rate limit of the /validatebatch endpoint
+	BATCH_VALIDATE_REQUESTS_PER_MINUTE = 5
+	// BATCH_VALIDATE_DEFAULT_CONCURRENCY - default number of chunks in flight
+	BATCH_VALIDATE_DEFAULT_CONCURRENCY = 2
+)
+
+// BatchOptions - how ValidateBatchWithOptions splits a list into requests
+type BatchOptions struct {
+	// ChunkSize is the number of emails per request (defaults to, and is
+	// capped at, BATCH_VALIDATE_MAX_EMAILS)
+	ChunkSize int
+	// Concurrency is the number of requests in flight at once (defaults to
+	// BATCH_VALIDATE_DEFAULT_CONCURRENCY)
+	Concurrency int
+	// RequestsPerMinute spaces out request starts (defaults to
+	// BATCH_VALIDATE_REQUESTS_PER_MINUTE; negative disables the limit)
+	RequestsPerMinute int
+}
+
+func (o BatchOptions) withDefaults() BatchOptions {
+	if o.ChunkSize <= 0 || o.ChunkSize > BATCH_VALIDATE_MAX_EMAILS {
+		o.ChunkSize = BATCH_VALIDATE_MAX_EMAILS
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = BATCH_VALIDATE_DEFAULT_CONCURRENCY
+	}
+	if o.RequestsPerMinute == 0 {
+		o.RequestsPerMinute = BATCH_VALIDATE_REQUESTS_PER_MINUTE
+	}
+	return o
+}
+
+// rateLimiter - hands out evenly spaced request slots
+type rateLimiter struct {
+	mutex     sync.Mutex
+	interval  time.Duration
+	next_slot time.Time
+}
+
+func newRateLimiter(requests_per_minute int) *rateLimiter {
+	limiter := &rateLimiter{}
+	if requests_per_minute > 0 {
+		limiter.interval = time.Minute / time.Duration(requests_per_minute)
+	}
+	return limiter
+}
+
+// wait - block until the caller may start its request
+func (r *rateLimiter) wait() {
+	if r.interval == 0 {
+		return
+	}
+	r.mutex.Lock()
+	now := time.Now()
+	slot := r.next_slot
+	if slot.Before(now) {
+		slot = now
+	}
+	r.next_slot = slot.Add(r.interval)
+	r.mutex.Unlock()
+	time.Sleep(time.Until(slot))
+}
+
+// validateBatchChunks - send a list as API-sized /validatebatch requests,
+// with bounded concurrency, and merge the responses in chunk order
+func validateBatchChunks(emails_list []EmailToValidate, options BatchOptions) (ValidateBatchResponse, error) {
+	options = options.withDefaults()
+	if len(emails_list) <= options.ChunkSize {
+		return validateBatchRequest(emails_list)
+	}
+
+	var chunks [][]EmailToValidate
+	for start := 0; start < len(emails_list); start += options.ChunkSize {
+		end := start + options.ChunkSize
+		if end > len(emails_list) {
+			end = len(emails_list)
+		}
+		chunks = append(chunks, emails_list[start:end])
+	}
+
+	chunk_responses := make([]ValidateBatchResponse, len(chunks))
+	chunk_indices := make(chan int)
+	limiter := newRateLimiter(options.RequestsPerMinute)
+
+	var first_error error
+	var error_mutex sync.Mutex
+	failed := func() bool {
+		error_mutex.Lock()
+		defer error_mutex.Unlock()
+		return first_error != nil
+	}
+
+	var wait_group sync.WaitGroup
+	for worker := 0; worker < options.Concurrency && worker < len(chunks); worker++ {
+		wait_group.Add(1)
+		go func() {
+			defer wait_group.Done()
+			for chunk_index := range chunk_indices {
+				limiter.wait()
+				if failed() {
+					continue
+				}
+				response, error_ := validateBatchRequest(chunks[chunk_index])
+				chunk_responses[chunk_index] = response
+				if error_ != nil {
+					error_mutex.Lock()
+					if first_error == nil {
+						first_error = fmt.Errorf("batch chunk %d of %d: %w", chunk_index+1, len(chunks), error_)
+					}
+					error_mutex.Unlock()
+				}
+			}
+		}()
+	}
+	for chunk_index := range chunks {
+		if failed() {
+			break
+		}
+		chunk_indices <- chunk_index
+	}
+	close(chunk_indices)
+	wait_group.Wait()
+
+	merged := ValidateBatchResponse{}
+	for _, response := range chunk_responses {
+		merged.EmailBatch = append(merged.EmailBatch, response.EmailBatch...)
+		merged.Errors = append(merged.Errors, response.Errors...)
+	}
+	return merged, first_error
+}
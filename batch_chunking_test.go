@@ -0,0 +1,126 @@
+package zerobouncego
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockEchoBatchValidateRequest - mock POST/validatebatch answering "valid" for
+// every email, in reverse order, and recording the size of each request;
+// requests containing `failing_email` get a 500 response
+func mockEchoBatchValidateRequest(chunk_sizes *[]int, failing_email string) {
+	var mutex sync.Mutex
+	httpmock.RegisterResponder("POST", `=~^(.*)`+ENDPOINT_BATCH_VALIDATE+`(.*)\z`,
+		func(req *http.Request) (*http.Response, error) {
+			payload := struct {
+				Emails []EmailToValidate `json:"email_batch"`
+			}{}
+			error_ := json.NewDecoder(req.Body).Decode(&payload)
+			if error_ != nil {
+				return nil, error_
+			}
+			mutex.Lock()
+			*chunk_sizes = append(*chunk_sizes, len(payload.Emails))
+			mutex.Unlock()
+
+			var results []string
+			for index := len(payload.Emails) - 1; index >= 0; index-- {
+				email := payload.Emails[index].EmailAddress
+				if email == failing_email {
+					return httpmock.NewStringResponse(500, `{"error": "`+sample_error_message+`"}`), nil
+				}
+				results = append(results, `{"address": "`+email+`", "status": "valid"}`)
+			}
+			return httpmock.NewStringResponse(200, `{"email_batch": [`+strings.Join(results, ",")+`], "errors": []}`), nil
+		},
+	)
+}
+
+func numberedEmails(count int) []EmailToValidate {
+	emails := make([]EmailToValidate, count)
+	for index := range emails {
+		emails[index] = EmailToValidate{EmailAddress: fmt.Sprintf("user%d@example.com", index)}
+	}
+	return emails
+}
+
+func TestValidateBatchSplitsLargeLists(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	var chunk_sizes []int
+	mockEchoBatchValidateRequest(&chunk_sizes, "")
+
+	emails := numberedEmails(450)
+	response, error_ := ValidateBatchWithOptions(emails, BatchOptions{Concurrency: 3, RequestsPerMinute: -1})
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	assert.ElementsMatch(t, []int{200, 200, 50}, chunk_sizes)
+	if assert.Len(t, response.EmailBatch, 450) {
+		for index, email_response := range response.EmailBatch {
+			assert.Equal(t, emails[index].EmailAddress, email_response.Address)
+			assert.Equal(t, emails[index].EmailAddress, email_response.SubmittedAddress)
+		}
+	}
+}
+
+func TestValidateBatchCustomChunkSize(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	var chunk_sizes []int
+	mockEchoBatchValidateRequest(&chunk_sizes, "")
+
+	response, error_ := ValidateBatchWithOptions(numberedEmails(25), BatchOptions{ChunkSize: 10, RequestsPerMinute: -1})
+	assert.Nil(t, error_)
+	assert.Len(t, response.EmailBatch, 25)
+	assert.ElementsMatch(t, []int{10, 10, 5}, chunk_sizes)
+
+	// small lists are sent as they are
+	chunk_sizes = nil
+	_, error_ = ValidateBatch(numberedEmails(BATCH_VALIDATE_MAX_EMAILS))
+	assert.Nil(t, error_)
+	assert.Equal(t, []int{BATCH_VALIDATE_MAX_EMAILS}, chunk_sizes)
+}
+
+func TestValidateBatchChunkFailure(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	var chunk_sizes []int
+	mockEchoBatchValidateRequest(&chunk_sizes, "user15@example.com")
+
+	response, error_ := ValidateBatchWithOptions(numberedEmails(40), BatchOptions{ChunkSize: 10, Concurrency: 1, RequestsPerMinute: -1})
+	if assert.NotNil(t, error_) {
+		assert.Contains(t, error_.Error(), "batch chunk 2 of 4")
+		assert.Contains(t, error_.Error(), sample_error_message)
+	}
+	// the first chunk succeeded, no chunk is sent after the failure
+	assert.Len(t, response.EmailBatch, 10)
+	assert.Equal(t, []int{10, 10}, chunk_sizes)
+}
+
+func TestRateLimiterSpacesRequests(t *testing.T) {
+	limiter := newRateLimiter(1200) // one slot every 50ms
+	start := time.Now()
+	for index := 0; index < 3; index++ {
+		limiter.wait()
+	}
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(100*time.Millisecond))
+
+	unlimited := newRateLimiter(-1)
+	start = time.Now()
+	for index := 0; index < 100; index++ {
+		unlimited.wait()
+	}
+	assert.Less(t, int64(time.Since(start)), int64(50*time.Millisecond))
+}
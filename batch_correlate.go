@@ -0,0 +1,157 @@
+package zerobouncego
+
+import (
+	"strconv"
+	"strings"
+)
+
+// BatchEntry - an email to batch validate, along with a key chosen by the
+// caller (eg: the ID of the row it originates from)
+type BatchEntry struct {
+	Key string
+	EmailToValidate
+}
+
+// BatchResult - outcome of one BatchEntry: either its validation result, or
+// the error the API reported about it; entries that got neither are flagged
+// as `Missing`
+type BatchResult struct {
+	Key      string
+	Input    EmailToValidate
+	Response *ValidateResponse
+	Error    *EmailBatchError
+	Missing  bool
+}
+
+// CorrelatedBatch - batch validation outcome, with one result per entry in
+// input order. Results and errors that could not be associated with any
+// entry (eg: errors concerning "all" emails) are kept separately.
+type CorrelatedBatch struct {
+	Results            []BatchResult
+	UnmatchedResponses []ValidateResponse
+	UnmatchedErrors    []EmailBatchError
+}
+
+// Missing - results of the entries that got neither a response nor an error
+func (c CorrelatedBatch) Missing() []BatchResult {
+	var missing []BatchResult
+	for _, result := range c.Results {
+		if result.Missing {
+			missing = append(missing, result)
+		}
+	}
+	return missing
+}
+
+// BatchEntriesFromEmails - wrap emails into entries keyed by their position
+// in the list ("0", "1", ...)
+func BatchEntriesFromEmails(emails_list []EmailToValidate) []BatchEntry {
+	entries := make([]BatchEntry, len(emails_list))
+	for index, email := range emails_list {
+		entries[index] = BatchEntry{Key: strconv.Itoa(index), EmailToValidate: email}
+	}
+	return entries
+}
+
+// ValidateBatchEntries - batch validate the entries (see
+// ValidateBatchWithOptions) and correlate the outcome with them. When a chunk
+// request fails, the results gathered so far are returned with the error and
+// the entries of the failed chunks are flagged as missing.
+func ValidateBatchEntries(entries []BatchEntry, options BatchOptions) (CorrelatedBatch, error) {
+	emails_list := make([]EmailToValidate, len(entries))
+	for index, entry := range entries {
+		emails_list[index] = entry.EmailToValidate
+	}
+	response, error_ := ValidateBatchWithOptions(emails_list, options)
+	return CorrelateBatch(entries, response), error_
+}
+
+// CorrelateBatch - pair each entry with its result or error from a batch
+// validation response. Items are matched on `SubmittedAddress` when set, then
+// on the address returned by the API, ignoring case and IDN encoding;
+// duplicated addresses are handed out in input order.
+func CorrelateBatch(entries []BatchEntry, response ValidateBatchResponse) CorrelatedBatch {
+	correlated := CorrelatedBatch{Results: make([]BatchResult, len(entries))}
+	index := newBatchEntryIndex(entries)
+	for position, entry := range entries {
+		correlated.Results[position] = BatchResult{Key: entry.Key, Input: entry.EmailToValidate}
+	}
+
+	for response_index := range response.EmailBatch {
+		email_response := response.EmailBatch[response_index]
+		position := index.take(email_response.SubmittedAddress, email_response.Address)
+		if position < 0 {
+			correlated.UnmatchedResponses = append(correlated.UnmatchedResponses, email_response)
+			continue
+		}
+		correlated.Results[position].Response = &email_response
+	}
+	for error_index := range response.Errors {
+		email_error := response.Errors[error_index]
+		position := index.take(email_error.SubmittedAddress, email_error.EmailAddress)
+		if position < 0 {
+			correlated.UnmatchedErrors = append(correlated.UnmatchedErrors, email_error)
+			continue
+		}
+		correlated.Results[position].Error = &email_error
+	}
+
+	for position := range correlated.Results {
+		correlated.Results[position].Missing = !index.matched[position]
+	}
+	return correlated
+}
+
+// batchEntryIndex - positions of the entries, by address as submitted and
+// by address as sent to the API (both lowercased)
+type batchEntryIndex struct {
+	submitted map[string][]int
+	sent      map[string][]int
+	matched   []bool
+}
+
+func newBatchEntryIndex(entries []BatchEntry) *batchEntryIndex {
+	index := &batchEntryIndex{
+		submitted: map[string][]int{},
+		sent:      map[string][]int{},
+		matched:   make([]bool, len(entries)),
+	}
+	for position, entry := range entries {
+		submitted_key := strings.ToLower(entry.EmailAddress)
+		index.submitted[submitted_key] = append(index.submitted[submitted_key], position)
+		sent_key := sentAddressKey(entry.EmailAddress)
+		index.sent[sent_key] = append(index.sent[sent_key], position)
+	}
+	return index
+}
+
+func sentAddressKey(email string) string {
+	return strings.ToLower(apiAddress(NormalizeEmail(email)))
+}
+
+// take - first unmatched position for the submitted address (if any), else
+// for the returned one; -1 when there is none
+func (b *batchEntryIndex) take(submitted_address, returned_address string) int {
+	if submitted_address != "" {
+		if position := b.first(b.submitted[strings.ToLower(submitted_address)]); position >= 0 {
+			return position
+		}
+	}
+	if returned_address == "" {
+		return -1
+	}
+	if position := b.first(b.submitted[strings.ToLower(returned_address)]); position >= 0 {
+		return position
+	}
+	return b.first(b.sent[sentAddressKey(returned_address)])
+}
+
+func (b *batchEntryIndex) first(positions []int) int {
+	for _, position := range positions {
+		if !b.matched[position] {
+			b.matched[position] = true
+			return position
+		}
+	}
+	return -1
+}
@@ -0,0 +1,126 @@
+package zerobouncego
+
+import (
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateBatchEntries(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	mockBatchValidateRequest()
+
+	correlated, error_ := ValidateBatchEntries([]BatchEntry{
+		{Key: "row-1", EmailToValidate: EmailToValidate{EmailAddress: "valid@example.com"}},
+		{Key: "row-2", EmailToValidate: EmailToValidate{EmailAddress: ""}},
+		{Key: "row-3", EmailToValidate: EmailToValidate{EmailAddress: "invalid@example.com", IPAddress: SANDBOX_IP}},
+	}, BatchOptions{})
+	if !assert.Nil(t, error_) || !assert.Len(t, correlated.Results, 3) {
+		t.FailNow()
+	}
+
+	first := correlated.Results[0]
+	assert.Equal(t, "row-1", first.Key)
+	assert.False(t, first.Missing)
+	if assert.NotNil(t, first.Response) {
+		assert.Equal(t, S_VALID, first.Response.Status)
+	}
+	assert.Nil(t, first.Error)
+
+	// the API reports empty addresses as "unknown", which matches no entry
+	second := correlated.Results[1]
+	assert.Equal(t, "row-2", second.Key)
+	assert.True(t, second.Missing)
+	assert.Nil(t, second.Response)
+	assert.Nil(t, second.Error)
+	if assert.Len(t, correlated.UnmatchedErrors, 1) {
+		assert.Equal(t, "unknown", correlated.UnmatchedErrors[0].EmailAddress)
+	}
+
+	third := correlated.Results[2]
+	assert.Equal(t, "row-3", third.Key)
+	assert.Equal(t, SANDBOX_IP, third.Input.IPAddress)
+	if assert.NotNil(t, third.Response) {
+		assert.Equal(t, S_INVALID, third.Response.Status)
+	}
+
+	missing := correlated.Missing()
+	if assert.Len(t, missing, 1) {
+		assert.Equal(t, "row-2", missing[0].Key)
+	}
+}
+
+func TestValidateBatchEntriesGlobalError(t *testing.T) {
+	Initialize("")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	mockBatchValidateRequest()
+
+	correlated, error_ := ValidateBatchEntries(BatchEntriesFromEmails(EmailsToValidate()), BatchOptions{})
+	assert.Nil(t, error_)
+	assert.Len(t, correlated.Missing(), len(EmailsToValidate()))
+	assert.Equal(t, "0", correlated.Results[0].Key)
+	if assert.Len(t, correlated.UnmatchedErrors, 1) {
+		assert.Equal(t, "all", correlated.UnmatchedErrors[0].EmailAddress)
+	}
+}
+
+func TestCorrelateBatchRawResponse(t *testing.T) {
+	entries := []BatchEntry{
+		{Key: "a", EmailToValidate: EmailToValidate{EmailAddress: "Dup@Example.com"}},
+		{Key: "b", EmailToValidate: EmailToValidate{EmailAddress: "user@bücher.de"}},
+		{Key: "c", EmailToValidate: EmailToValidate{EmailAddress: "dup@example.com"}},
+		{Key: "d", EmailToValidate: EmailToValidate{EmailAddress: "broken@example.com"}},
+		{Key: "e", EmailToValidate: EmailToValidate{EmailAddress: "lost@example.com"}},
+	}
+	// as returned by the API: shuffled, lowercased and punycode-encoded
+	response := ValidateBatchResponse{
+		EmailBatch: []ValidateResponse{
+			{Address: "user@xn--bcher-kva.de", Status: S_VALID},
+			{Address: "dup@example.com", Status: S_CATCH_ALL},
+			{Address: "dup@example.com", Status: S_CATCH_ALL},
+			{Address: "stranger@example.com", Status: S_VALID},
+		},
+		Errors: []EmailBatchError{
+			{EmailAddress: "BROKEN@example.com", Error: sample_error_message},
+		},
+	}
+
+	correlated := CorrelateBatch(entries, response)
+	if !assert.Len(t, correlated.Results, 5) {
+		t.FailNow()
+	}
+	for _, key := range []int{0, 1, 2} {
+		if assert.NotNilf(t, correlated.Results[key].Response, "no response for %s", entries[key].Key) {
+			assert.Equal(t, entries[key].EmailAddress, correlated.Results[key].Input.EmailAddress)
+		}
+	}
+	assert.Equal(t, "user@xn--bcher-kva.de", correlated.Results[1].Response.Address)
+	if assert.NotNil(t, correlated.Results[3].Error) {
+		assert.Equal(t, sample_error_message, correlated.Results[3].Error.Error)
+	}
+	assert.True(t, correlated.Results[4].Missing)
+	if assert.Len(t, correlated.UnmatchedResponses, 1) {
+		assert.Equal(t, "stranger@example.com", correlated.UnmatchedResponses[0].Address)
+	}
+	assert.Empty(t, correlated.UnmatchedErrors)
+}
+
+func TestCorrelateBatchPrefersSubmittedAddress(t *testing.T) {
+	entries := []BatchEntry{
+		{Key: "spaced", EmailToValidate: EmailToValidate{EmailAddress: " someone@example.com"}},
+		{Key: "plain", EmailToValidate: EmailToValidate{EmailAddress: "someone@example.com"}},
+	}
+	response := ValidateBatchResponse{EmailBatch: []ValidateResponse{
+		{Address: "someone@example.com", SubmittedAddress: "someone@example.com", Status: S_VALID},
+		{Address: "someone@example.com", SubmittedAddress: " someone@example.com", Status: S_VALID},
+	}}
+
+	correlated := CorrelateBatch(entries, response)
+	assert.Equal(t, " someone@example.com", correlated.Results[0].Response.SubmittedAddress)
+	assert.Equal(t, "someone@example.com", correlated.Results[1].Response.SubmittedAddress)
+	assert.Empty(t, correlated.Missing())
+}
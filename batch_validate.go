@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"sort"
 	"strings"
 )
 
@@ -18,6 +19,10 @@ type EmailToValidate struct {
 type EmailBatchError struct {
 	Error        string `json:"error"`
 	EmailAddress string `json:"email_address"`
+
+	// SubmittedAddress is the address as passed to ValidateBatch (empty when
+	// the error cannot be associated with a single submitted address)
+	SubmittedAddress string `json:"-"`
 }
 
 // ValidateBatchResponse represents the structure of a 200OK batch validate
@@ -28,8 +33,141 @@ type ValidateBatchResponse struct {
 }
 
 // ValidateBatch given a list of emails (and, optionally, their IPs), validate
-// them and return both validation details and errors about the emails sent
+// them and return both validation details and errors about the emails sent.
+// Lists larger than `BATCH_VALIDATE_MAX_EMAILS` are split into several
+// requests, see `ValidateBatchWithOptions`.
 func ValidateBatch(emails_list []EmailToValidate) (ValidateBatchResponse, error) {
+	return ValidateBatchWithOptions(emails_list, BatchOptions{})
+}
+
+// ValidateBatchWithOptions - same as ValidateBatch, with control over how the
+// list is split into API-sized chunks. Results and errors of all chunks are
+// merged in input order; if a chunk request fails, the results gathered so
+// far are returned along with the error.
+func ValidateBatchWithOptions(emails_list []EmailToValidate, options BatchOptions) (ValidateBatchResponse, error) {
+	submitted := submittedAddresses{}
+	emails_to_send := make([]EmailToValidate, 0, len(emails_list))
+	// responses resolved locally, without sending the email
+	var local_responses []ValidateResponse
+	var local_positions []int
+	// address sent for each position, results being cached under it
+	sent_addresses := make([]string, len(emails_list))
+
+	for position, email := range emails_list {
+		submitted_email := email.EmailAddress
+		if SYNTAX_PRECHECK {
+			precheck := PrecheckEmail(email.EmailAddress)
+			if !precheck.Valid {
+				local_responses = append(local_responses, *precheck.Response())
+				local_positions = append(local_positions, position)
+				continue
+			}
+			email.EmailAddress = precheck.Normalized
+		}
+		email.EmailAddress = apiAddress(email.EmailAddress)
+		if cached_response, ok := cachedValidateResponse(email.EmailAddress, email.IPAddress); ok {
+			cached_response.SubmittedAddress = submitted_email
+			local_responses = append(local_responses, *cached_response)
+			local_positions = append(local_positions, position)
+			continue
+		}
+		submitted.add(email.EmailAddress, position)
+		sent_addresses[position] = email.EmailAddress
+		emails_to_send = append(emails_to_send, email)
+	}
+	if len(emails_to_send) == 0 && len(local_responses) > 0 {
+		return ValidateBatchResponse{EmailBatch: local_responses}, nil
+	}
+
+	response_object, error_ := validateBatchChunks(emails_to_send, options)
+
+	// associate results with the submitted emails and restore the input order
+	response_positions := make([]int, len(response_object.EmailBatch))
+	var cache_entries []CacheEntry
+	for index := range response_object.EmailBatch {
+		email_response := &response_object.EmailBatch[index]
+		response_positions[index] = submitted.take(email_response.Address)
+		if response_positions[index] < 0 {
+			continue
+		}
+		email_response.SubmittedAddress = emails_list[response_positions[index]].EmailAddress
+		ip_address := emails_list[response_positions[index]].IPAddress
+		if entry, ok := cacheEntry(sent_addresses[response_positions[index]], ip_address, email_response); ok {
+			cache_entries = append(cache_entries, entry)
+		}
+	}
+	cacheValidateResponses(cache_entries)
+	error_positions := make([]int, len(response_object.Errors))
+	for index := range response_object.Errors {
+		email_error := &response_object.Errors[index]
+		error_positions[index] = submitted.take(email_error.EmailAddress)
+		if error_positions[index] >= 0 {
+			email_error.SubmittedAddress = emails_list[error_positions[index]].EmailAddress
+		}
+	}
+	response_object.EmailBatch = append(response_object.EmailBatch, local_responses...)
+	response_positions = append(response_positions, local_positions...)
+	sortByPosition(response_object.EmailBatch, response_positions)
+	sortByPosition(response_object.Errors, error_positions)
+	return response_object, error_
+}
+
+// submittedAddresses - maps the addresses sent to the API back to their
+// position in the caller's list; duplicates are handed out in input order
+type submittedAddresses map[string][]int
+
+func (s submittedAddresses) add(sent_address string, position int) {
+	key := strings.ToLower(sent_address)
+	s[key] = append(s[key], position)
+}
+
+// take - position of the submitted email matching a returned address (-1 when
+// there is none, eg: errors concerning "all" emails)
+func (s submittedAddresses) take(returned_address string) int {
+	for _, key := range []string{strings.ToLower(returned_address), strings.ToLower(apiAddress(returned_address))} {
+		if queue := s[key]; len(queue) > 0 {
+			s[key] = queue[1:]
+			return queue[0]
+		}
+	}
+	return -1
+}
+
+// sortByPosition - stable sort of a slice (of responses or errors) by the
+// input position of each item; items without a position are kept at the end
+func sortByPosition(items interface{}, positions []int) {
+	indices := make([]int, len(positions))
+	for index := range indices {
+		indices[index] = index
+	}
+	rank := func(position int) int {
+		if position < 0 {
+			return int(^uint(0) >> 1)
+		}
+		return position
+	}
+	sort.SliceStable(indices, func(i, j int) bool {
+		return rank(positions[indices[i]]) < rank(positions[indices[j]])
+	})
+
+	switch typed_items := items.(type) {
+	case []ValidateResponse:
+		sorted := make([]ValidateResponse, len(typed_items))
+		for index, original_index := range indices {
+			sorted[index] = typed_items[original_index]
+		}
+		copy(typed_items, sorted)
+	case []EmailBatchError:
+		sorted := make([]EmailBatchError, len(typed_items))
+		for index, original_index := range indices {
+			sorted[index] = typed_items[original_index]
+		}
+		copy(typed_items, sorted)
+	}
+}
+
+// validateBatchRequest - perform the actual /validatebatch request
+func validateBatchRequest(emails_list []EmailToValidate) (ValidateBatchResponse, error) {
 	response_object := &ValidateBatchResponse{}
 	var error_ error
 
@@ -0,0 +1,277 @@
+package zerobouncego
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// BulkJobKind - API a bulk job goes through
+type BulkJobKind string
+
+const (
+	BulkJobValidation BulkJobKind = "validation"
+	BulkJobScoring    BulkJobKind = "scoring"
+)
+
+// BulkJobStage - last stage a bulk job went through
+type BulkJobStage string
+
+const (
+	BulkJobStageNew        BulkJobStage = ""
+	BulkJobStageSubmitted  BulkJobStage = "submitted"
+	BulkJobStageCompleted  BulkJobStage = "completed"
+	BulkJobStageDownloaded BulkJobStage = "downloaded"
+	BulkJobStageDone       BulkJobStage = "done"
+	// BulkJobStageFailed - the processing of the file failed
+	BulkJobStageFailed BulkJobStage = "failed"
+	// BulkJobStageDeleted - the file was deleted from the server
+	BulkJobStageDeleted BulkJobStage = "deleted"
+)
+
+// bulkJobStageOrder - position of the stages; failed and deleted jobs are
+// terminal, past every other stage
+var bulkJobStageOrder = map[BulkJobStage]int{
+	BulkJobStageNew:        0,
+	BulkJobStageSubmitted:  1,
+	BulkJobStageCompleted:  2,
+	BulkJobStageDownloaded: 3,
+	BulkJobStageDone:       4,
+	BulkJobStageFailed:     5,
+	BulkJobStageDeleted:    5,
+}
+
+// reached - whether the stage is `stage` or a later one
+func (b BulkJobStage) reached(stage BulkJobStage) bool {
+	return bulkJobStageOrder[b] >= bulkJobStageOrder[stage]
+}
+
+// bulkJobEndpoints - endpoints used by a kind of bulk job
+type bulkJobEndpoints struct {
+	send, status, result, delete string
+}
+
+func (k BulkJobKind) endpoints() (bulkJobEndpoints, error) {
+	switch k {
+	case BulkJobValidation, "":
+		return bulkJobEndpoints{ENDPOINT_FILE_SEND, ENDPOINT_FILE_STATUS, ENDPOINT_FILE_RESULT, ENDPOINT_FILE_DELETE}, nil
+	case BulkJobScoring:
+		return bulkJobEndpoints{ENDPOINT_SCORING_SEND, ENDPOINT_SCORING_STATUS, ENDPOINT_SCORING_RESULT, ENDPOINT_SCORING_DELETE}, nil
+	}
+	return bulkJobEndpoints{}, fmt.Errorf("unknown bulk job kind %q", k)
+}
+
+// BulkJobOptions - how RunBulkJob processes a file
+type BulkJobOptions struct {
+	// Kind defaults to BulkJobValidation
+	Kind            BulkJobKind
+	RemoveDuplicate bool
+	// Wait tunes the status polling
+	Wait WaitOptions
+	// GetFile holds the getfile query parameters and download progress callback
+	GetFile GetFileOptions
+	// ResultPath, if set, receives the result file (with resumable
+	// downloads, see GenericResultFetchToFile); otherwise the result is only
+	// held in memory
+	ResultPath string
+	// DeleteRemoteFile deletes the file from the server once its results
+	// are fetched
+	DeleteRemoteFile bool
+	// OnStage, if set, receives the job after each stage it completes, eg: to
+	// persist its file ID and resume it after a crash
+	OnStage func(job BulkJob)
+}
+
+// BulkJob - state of a bulk job, enough to resume it with ResumeBulkJob
+type BulkJob struct {
+	Kind       BulkJobKind
+	FileId     string
+	Stage      BulkJobStage
+	ResultPath string
+	// EmailAddressColumn is the column of the addresses in the submitted
+	// file, and so in the result file (defaults to 1)
+	EmailAddressColumn int
+	// Status is the last file status fetched
+	Status *FileStatusResponse
+}
+
+// BulkJobResult - outcome of a bulk job: its final state and the result rows
+// (ValidationRows or ScoringRows, depending on its kind)
+type BulkJobResult struct {
+	BulkJob
+	ValidationRows []BulkValidationRow
+	ScoringRows    []AiScoringRow
+}
+
+// RunBulkJob - submit a file, wait for its completion, download and parse
+// its results and, if requested, delete it from the server. When it fails
+// after the submission, the returned job can be passed to ResumeBulkJob.
+func RunBulkJob(ctx context.Context, csv_file CsvFile, options BulkJobOptions) (*BulkJobResult, error) {
+	endpoints, error_ := options.Kind.endpoints()
+	if error_ != nil {
+		return nil, error_
+	}
+	job := BulkJob{Kind: options.Kind, ResultPath: options.ResultPath, EmailAddressColumn: csv_file.EmailAddressColumn}
+	if job.Kind == "" {
+		job.Kind = BulkJobValidation
+	}
+
+	submit_response, error_ := GenericFileSubmit(csv_file, options.RemoveDuplicate, endpoints.send)
+	if submit_response != nil && submit_response.Success && submit_response.FileId != "" {
+		// submitted, even if it could not be recorded
+		job.FileId = submit_response.FileId
+		job.Stage = BulkJobStageSubmitted
+	}
+	if error_ != nil {
+		return &BulkJobResult{BulkJob: job}, error_
+	}
+	if job.FileId == "" {
+		return &BulkJobResult{BulkJob: job}, fmt.Errorf("file submission failed: %v", submit_response.Message)
+	}
+	if options.OnStage != nil {
+		options.OnStage(job)
+	}
+	return ResumeBulkJob(ctx, job, options)
+}
+
+// RunBulkJobFromPath - RunBulkJob for the csv file at the given path
+func RunBulkJobFromPath(ctx context.Context, path_to_file string, has_header bool, email_column int, options BulkJobOptions) (*BulkJobResult, error) {
+	csv_file, error_ := ImportCsvFile(path_to_file, has_header, email_column)
+	if error_ != nil {
+		return nil, error_
+	}
+	if closer, ok := csv_file.File.(io.Closer); ok {
+		defer closer.Close()
+	}
+	return RunBulkJob(ctx, *csv_file, options)
+}
+
+// ResumeBulkJob - carry a submitted job on from its stage: wait for the
+// file, download and parse its results (stage BulkJobStageDone), delete it if
+// requested (stage BulkJobStageDeleted). A job known
+// only by its file ID can be resumed with `BulkJob{Kind: ..., FileId: ...}`.
+// Failed and deleted jobs cannot be resumed: an error wrapping ErrFileFailed
+// or ErrFileDeleted is returned right away.
+// `options.Kind` and `options.ResultPath` are ignored in favour of the job's.
+func ResumeBulkJob(ctx context.Context, job BulkJob, options BulkJobOptions) (*BulkJobResult, error) {
+	if job.Kind == "" {
+		job.Kind = BulkJobValidation
+	}
+	result := &BulkJobResult{BulkJob: job}
+	endpoints, error_ := job.Kind.endpoints()
+	if error_ != nil {
+		return result, error_
+	}
+	if job.FileId == "" {
+		return result, errors.New("bulk job has no file ID")
+	}
+	switch job.Stage {
+	case BulkJobStageFailed:
+		return result, fmt.Errorf("bulk job %s cannot be resumed: %w", job.FileId, ErrFileFailed)
+	case BulkJobStageDeleted:
+		return result, fmt.Errorf("bulk job %s cannot be resumed: %w", job.FileId, ErrFileDeleted)
+	}
+	stage_done := func(stage BulkJobStage) error {
+		result.Stage = stage
+		if options.OnStage != nil {
+			options.OnStage(result.BulkJob)
+		}
+		return recordJobState(result.FileId, stage, result.ResultPath, nil)
+	}
+	// failed - record the error, along with the state it leads to, if any
+	failed := func(error_ error) (*BulkJobResult, error) {
+		state := BulkJobStage("")
+		if errors.Is(error_, ErrFileFailed) {
+			state = BulkJobStageFailed
+		} else if errors.Is(error_, ErrFileDeleted) {
+			state = BulkJobStageDeleted
+		}
+		recordJobState(result.FileId, state, "", error_)
+		return result, error_
+	}
+
+	// a downloaded result is only kept when saved to a file
+	has_result_file := false
+	if result.ResultPath != "" && result.Stage.reached(BulkJobStageDownloaded) {
+		_, error_ := os.Stat(result.ResultPath)
+		has_result_file = error_ == nil
+	}
+
+	if !has_result_file && !result.Stage.reached(BulkJobStageCompleted) {
+		result.Status, error_ = WaitForFile(ctx, result.FileId, endpoints.status, options.Wait)
+		if error_ != nil {
+			return failed(error_)
+		}
+		if error_ = stage_done(BulkJobStageCompleted); error_ != nil {
+			return result, error_
+		}
+	}
+
+	var contents io.Reader
+	if has_result_file {
+		file, error_ := os.Open(result.ResultPath)
+		if error_ != nil {
+			return failed(error_)
+		}
+		defer file.Close()
+		contents = file
+	} else {
+		contents, error_ = downloadBulkJobResult(result.BulkJob, endpoints, options)
+		if error_ != nil {
+			return failed(error_)
+		}
+		if closer, ok := contents.(io.Closer); ok {
+			defer closer.Close()
+		}
+		if error_ = stage_done(BulkJobStageDownloaded); error_ != nil {
+			return result, error_
+		}
+	}
+
+	if result.Kind == BulkJobScoring {
+		result.ScoringRows, error_ = ParseScoringResults(contents, &ResultParseOptions{EmailAddressColumn: result.EmailAddressColumn})
+	} else {
+		result.ValidationRows, error_ = ParseValidationResults(contents, &ResultParseOptions{
+			EmailAddressColumn: result.EmailAddressColumn,
+			DownloadType:       options.GetFile.DownloadType,
+		})
+	}
+	if error_ != nil {
+		return failed(error_)
+	}
+
+	if error_ = stage_done(BulkJobStageDone); error_ != nil {
+		return result, error_
+	}
+	if options.DeleteRemoteFile {
+		delete_response, error_ := GenericFileDelete(result.FileId, endpoints.delete)
+		if error_ == nil && !delete_response.Success {
+			error_ = fmt.Errorf("%v", delete_response.Message)
+		}
+		if error_ != nil {
+			return failed(fmt.Errorf("results fetched, but the remote file could not be deleted: %w", error_))
+		}
+		if error_ = stage_done(BulkJobStageDeleted); error_ != nil {
+			return result, error_
+		}
+	}
+	return result, nil
+}
+
+// downloadBulkJobResult - fetch the result of a job, into its result file if
+// it has one (the returned reader is then that file), or into memory
+func downloadBulkJobResult(job BulkJob, endpoints bulkJobEndpoints, options BulkJobOptions) (io.Reader, error) {
+	if job.ResultPath == "" {
+		contents := &bytes.Buffer{}
+		error_ := genericResultFetch(job.FileId, endpoints.result, contents, &options.GetFile, job.Kind == BulkJobScoring)
+		return contents, error_
+	}
+	error_ := GenericResultFetchToFile(job.FileId, endpoints.result, job.ResultPath, &DownloadOptions{GetFileOptions: options.GetFile})
+	if error_ != nil {
+		return nil, error_
+	}
+	return os.Open(job.ResultPath)
+}
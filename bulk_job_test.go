@@ -0,0 +1,178 @@
+package zerobouncego
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const sample_scoring_result = "\"Email Address\",\"ZeroBounceQualityScore\"\n" +
+	"\"valid@example.com\",\"10\"\n" +
+	"\"unknown@example.com\",\"3.5\"\n"
+
+// mockSendFile - mock a sendfile endpoint accepting any file
+func mockSendFile(endpoint string) {
+	httpmock.RegisterResponder("POST", `=~^(.*)`+endpoint+`(.*)\z`,
+		httpmock.NewStringResponder(201, send_file_response_200))
+}
+
+// mockResultFile - mock a getfile endpoint serving the given contents
+func mockResultFile(endpoint, contents string) {
+	httpmock.RegisterResponder("GET", `=~^(.*)`+endpoint+`(.*)\z`,
+		func(request *http.Request) (*http.Response, error) {
+			response := httpmock.NewStringResponse(200, contents)
+			response.Header.Set("Content-Type", CONTENT_TYPE_OCTET_STREAM)
+			return response, nil
+		},
+	)
+}
+
+func TestRunBulkJobValidation(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	mockSendFile(ENDPOINT_FILE_SEND)
+	mockStatusSequence(ENDPOINT_FILE_STATUS, fileStatusBody("Processing", "50%", ""), sample_file_validation_status_200_ok)
+	mockResultFile(ENDPOINT_FILE_RESULT, sample_list_validation_result)
+	mockOkResponse("GET", ENDPOINT_FILE_DELETE, sample_validation_delete_200_success)
+
+	var stages []BulkJobStage
+	csv_file := CsvFile{File: strings.NewReader("valid@example.com\nuser@gmal.com\n"), FileName: "emails.csv", EmailAddressColumn: 1}
+	result, error_ := RunBulkJob(context.Background(), csv_file, BulkJobOptions{
+		Wait:             fastWait,
+		DeleteRemoteFile: true,
+		OnStage:          func(job BulkJob) { stages = append(stages, job.Stage) },
+	})
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	assert.Equal(t, BulkJobValidation, result.Kind)
+	assert.Equal(t, testing_file_id, result.FileId)
+	assert.Equal(t, BulkJobStageDeleted, result.Stage)
+	assert.Equal(t, []BulkJobStage{BulkJobStageSubmitted, BulkJobStageCompleted, BulkJobStageDownloaded, BulkJobStageDone, BulkJobStageDeleted}, stages)
+	if assert.Len(t, result.ValidationRows, 2) {
+		assert.Equal(t, S_VALID, result.ValidationRows[0].Status)
+		assert.Equal(t, "USER@GMAL.COM", result.ValidationRows[1].Address)
+		assert.Equal(t, SS_POSSIBLE_TYPO, result.ValidationRows[1].SubStatus)
+	}
+	assert.Nil(t, result.ScoringRows)
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()["GET =~^(.*)"+ENDPOINT_FILE_DELETE+`(.*)\z`])
+}
+
+func TestRunBulkJobScoringFromPath(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	mockSendFile(ENDPOINT_SCORING_SEND)
+	mockStatusSequence(ENDPOINT_SCORING_STATUS, sample_file_validation_status_200_ok)
+	mockResultFile(ENDPOINT_SCORING_RESULT, sample_scoring_result)
+
+	directory := t.TempDir()
+	path_to_file := filepath.Join(directory, "emails.csv")
+	assert.Nil(t, os.WriteFile(path_to_file, []byte("email\nvalid@example.com\nunknown@example.com\n"), 0644))
+	result_path := filepath.Join(directory, "scores.csv")
+
+	result, error_ := RunBulkJobFromPath(context.Background(), path_to_file, true, 1, BulkJobOptions{
+		Kind:       BulkJobScoring,
+		Wait:       fastWait,
+		ResultPath: result_path,
+	})
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	if assert.Len(t, result.ScoringRows, 2) {
+		assert.Equal(t, "valid@example.com", result.ScoringRows[0].Email)
+		assert.Equal(t, 10.0, result.ScoringRows[0].Score)
+		assert.Equal(t, 3.5, result.ScoringRows[1].Score)
+	}
+	assert.Equal(t, result_path, result.ResultPath)
+	saved, _ := os.ReadFile(result_path)
+	assert.Equal(t, sample_scoring_result, string(saved))
+	assert.Equal(t, 0, httpmock.GetCallCountInfo()["GET =~^(.*)"+ENDPOINT_SCORING_DELETE+`(.*)\z`])
+}
+
+func TestRunBulkJobFailures(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	_, error_ := RunBulkJob(context.Background(), CsvFile{}, BulkJobOptions{Kind: "unknown"})
+	assert.NotNil(t, error_)
+
+	mockSendFile(ENDPOINT_FILE_SEND)
+	mockStatusSequence(ENDPOINT_FILE_STATUS, fileStatusBody("Failed", "0%", ""))
+	result, error_ := RunBulkJob(context.Background(), CsvFile{File: strings.NewReader("a@example.com\n"), FileName: "emails.csv", EmailAddressColumn: 1}, BulkJobOptions{Wait: fastWait})
+	assert.True(t, errors.Is(error_, ErrFileFailed))
+	assert.Equal(t, testing_file_id, result.FileId)
+	assert.Equal(t, BulkJobStageSubmitted, result.Stage)
+
+	_, error_ = ResumeBulkJob(context.Background(), BulkJob{}, BulkJobOptions{})
+	assert.NotNil(t, error_)
+
+	// terminal stages fail right away, without any request
+	httpmock.ZeroCallCounters()
+	_, error_ = ResumeBulkJob(context.Background(), BulkJob{FileId: testing_file_id, Stage: BulkJobStageFailed}, BulkJobOptions{Wait: fastWait})
+	assert.True(t, errors.Is(error_, ErrFileFailed))
+	_, error_ = ResumeBulkJob(context.Background(), BulkJob{FileId: testing_file_id, Stage: BulkJobStageDeleted}, BulkJobOptions{Wait: fastWait})
+	assert.True(t, errors.Is(error_, ErrFileDeleted))
+	assert.Equal(t, 0, httpmock.GetTotalCallCount())
+}
+
+func TestResumeBulkJob(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	mockStatusSequence(ENDPOINT_FILE_STATUS, sample_file_validation_status_200_ok)
+	mockResultFile(ENDPOINT_FILE_RESULT, sample_list_validation_result)
+	mockOkResponse("GET", ENDPOINT_FILE_DELETE, sample_validation_delete_200_success)
+	status_calls := "GET =~^(.*)" + ENDPOINT_FILE_STATUS + `(.*)\z`
+	result_calls := "GET =~^(.*)" + ENDPOINT_FILE_RESULT + `(.*)\z`
+
+	// known only by its file ID: waits, downloads and parses
+	result, error_ := ResumeBulkJob(context.Background(), BulkJob{FileId: testing_file_id}, BulkJobOptions{Wait: fastWait})
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	assert.Len(t, result.ValidationRows, 2)
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()[status_calls])
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()[result_calls])
+
+	// completed: only downloads
+	httpmock.ZeroCallCounters()
+	result_path := filepath.Join(t.TempDir(), "results.csv")
+	job := BulkJob{FileId: testing_file_id, Stage: BulkJobStageCompleted, ResultPath: result_path}
+	result, error_ = ResumeBulkJob(context.Background(), job, BulkJobOptions{})
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	assert.Len(t, result.ValidationRows, 2)
+	assert.Equal(t, 0, httpmock.GetCallCountInfo()[status_calls])
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()[result_calls])
+
+	// downloaded into its result file: parses it and deletes the remote file
+	httpmock.ZeroCallCounters()
+	job.Stage = BulkJobStageDownloaded
+	result, error_ = ResumeBulkJob(context.Background(), job, BulkJobOptions{DeleteRemoteFile: true})
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	assert.Len(t, result.ValidationRows, 2)
+	assert.Equal(t, BulkJobStageDeleted, result.Stage)
+	assert.Equal(t, 0, httpmock.GetCallCountInfo()[status_calls])
+	assert.Equal(t, 0, httpmock.GetCallCountInfo()[result_calls])
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()["GET =~^(.*)"+ENDPOINT_FILE_DELETE+`(.*)\z`])
+
+	// a failed deletion keeps the rows, the results being fetched
+	mockOkResponse("GET", ENDPOINT_FILE_DELETE, sample_validation_delete_200_not_success)
+	result, error_ = ResumeBulkJob(context.Background(), job, BulkJobOptions{DeleteRemoteFile: true})
+	assert.NotNil(t, error_)
+	assert.Len(t, result.ValidationRows, 2)
+	assert.Equal(t, BulkJobStageDone, result.Stage)
+}
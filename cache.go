@@ -0,0 +1,349 @@
+package zerobouncego
+
+import (
+	"container/list"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ValidationCache - storage for validation results, consulted by Validate,
+// ValidateWithTimeout and ValidateBatch when set as `VALIDATION_CACHE`.
+// Implementations must be safe for concurrent use and should return copies,
+// such that callers can modify the responses they get.
+type ValidationCache interface {
+	// Get returns the response stored under the key, if present and not expired
+	Get(key string) (*ValidateResponse, bool)
+	// Set stores a response under the key for the given duration
+	Set(key string, response *ValidateResponse, ttl time.Duration)
+}
+
+// CacheEntry - a result to store, see BatchValidationCache
+type CacheEntry struct {
+	Key      string
+	Response *ValidateResponse
+	TTL      time.Duration
+}
+
+// BatchValidationCache - optional interface of caches able to store several
+// results at once, used by ValidateBatch
+type BatchValidationCache interface {
+	ValidationCache
+	// SetMany stores every entry, as Set does
+	SetMany(entries []CacheEntry)
+}
+
+// VALIDATION_CACHE - cache of validation results (nil disables caching)
+var VALIDATION_CACHE ValidationCache
+
+// CACHE_TTL_BY_STATUS - how long results are cached, by status; statuses not
+// listed use `CACHE_DEFAULT_TTL` and a zero duration disables caching
+var CACHE_TTL_BY_STATUS = map[string]time.Duration{
+	S_VALID:       30 * 24 * time.Hour,
+	S_INVALID:     30 * 24 * time.Hour,
+	S_SPAMTRAP:    30 * 24 * time.Hour,
+	S_ABUSE:       30 * 24 * time.Hour,
+	S_DO_NOT_MAIL: 7 * 24 * time.Hour,
+	S_CATCH_ALL:   7 * 24 * time.Hour,
+	S_UNKNOWN:     time.Hour,
+}
+
+// CACHE_DEFAULT_TTL - cache duration of statuses missing from `CACHE_TTL_BY_STATUS`
+var CACHE_DEFAULT_TTL = time.Hour
+
+// CacheStats - hit and miss counters of `VALIDATION_CACHE` lookups
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// HitRatio - ratio of lookups served from the cache (0 without lookups)
+func (c CacheStats) HitRatio() float64 {
+	if c.Hits+c.Misses == 0 {
+		return 0
+	}
+	return float64(c.Hits) / float64(c.Hits+c.Misses)
+}
+
+var cacheHits, cacheMisses uint64
+
+// ValidationCacheStats - lookup counters since start or the last reset
+func ValidationCacheStats() CacheStats {
+	return CacheStats{Hits: atomic.LoadUint64(&cacheHits), Misses: atomic.LoadUint64(&cacheMisses)}
+}
+
+// ResetValidationCacheStats - reset the lookup counters
+func ResetValidationCacheStats() {
+	atomic.StoreUint64(&cacheHits, 0)
+	atomic.StoreUint64(&cacheMisses, 0)
+}
+
+// CacheKey - key under which the result of an address is cached: the
+// normalized address (see NormalizeEmail), lowercased, followed by the IP
+// address it was validated with, if any, as the geolocation fields of the
+// result depend on it (eg: "user@example.com|203.0.113.7")
+func CacheKey(email, ip_address string) string {
+	key := strings.ToLower(NormalizeEmail(email))
+	if ip_address = strings.TrimSpace(ip_address); ip_address != "" {
+		key += "|" + ip_address
+	}
+	return key
+}
+
+// CacheTTL - cache duration of a result with the given status
+func CacheTTL(status string) time.Duration {
+	if ttl, ok := CACHE_TTL_BY_STATUS[status]; ok {
+		return ttl
+	}
+	return CACHE_DEFAULT_TTL
+}
+
+// cachedValidateResponse - look an address up in `VALIDATION_CACHE`
+func cachedValidateResponse(email, ip_address string) (*ValidateResponse, bool) {
+	cache := VALIDATION_CACHE
+	if cache == nil {
+		return nil, false
+	}
+	response, ok := cache.Get(CacheKey(email, ip_address))
+	if ok && response != nil {
+		atomic.AddUint64(&cacheHits, 1)
+		return response, true
+	}
+	atomic.AddUint64(&cacheMisses, 1)
+	return nil, false
+}
+
+// cacheEntry - entry of a successful result to store, false if the result
+// is not to be cached
+func cacheEntry(email, ip_address string, response *ValidateResponse) (CacheEntry, bool) {
+	if response == nil || response.Status == "" {
+		return CacheEntry{}, false
+	}
+	ttl := CacheTTL(response.Status)
+	return CacheEntry{Key: CacheKey(email, ip_address), Response: response, TTL: ttl}, ttl > 0
+}
+
+// cacheValidateResponse - store a successful result in `VALIDATION_CACHE`
+func cacheValidateResponse(email, ip_address string, response *ValidateResponse) {
+	cache := VALIDATION_CACHE
+	if cache == nil {
+		return
+	}
+	if entry, ok := cacheEntry(email, ip_address, response); ok {
+		cache.Set(entry.Key, entry.Response, entry.TTL)
+	}
+}
+
+// cacheValidateResponses - store several entries in `VALIDATION_CACHE`, at
+// once when it is a BatchValidationCache
+func cacheValidateResponses(entries []CacheEntry) {
+	cache := VALIDATION_CACHE
+	if cache == nil || len(entries) == 0 {
+		return
+	}
+	if batch_cache, ok := cache.(BatchValidationCache); ok {
+		batch_cache.SetMany(entries)
+		return
+	}
+	for _, entry := range entries {
+		cache.Set(entry.Key, entry.Response, entry.TTL)
+	}
+}
+
+func copyValidateResponse(response *ValidateResponse) *ValidateResponse {
+	response_copy := *response
+	return &response_copy
+}
+
+type memoryCacheEntry struct {
+	key        string
+	response   ValidateResponse
+	expires_at time.Time
+}
+
+// MemoryCache - in-memory, least recently used validation cache
+type MemoryCache struct {
+	capacity int
+	mutex    sync.Mutex
+	entries  map[string]*list.Element
+	order    *list.List
+	now      func() time.Time
+}
+
+// NewMemoryCache - create an LRU cache holding at most `capacity` results
+// (a capacity below 1 means no limit)
+func NewMemoryCache(capacity int) *MemoryCache {
+	return &MemoryCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+// Get - see ValidationCache
+func (c *MemoryCache) Get(key string) (*ValidateResponse, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*memoryCacheEntry)
+	if !c.now().Before(entry.expires_at) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return copyValidateResponse(&entry.response), true
+}
+
+// Set - see ValidationCache
+func (c *MemoryCache) Set(key string, response *ValidateResponse, ttl time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.set(key, response, ttl)
+}
+
+// SetMany - see BatchValidationCache
+func (c *MemoryCache) SetMany(entries []CacheEntry) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, entry := range entries {
+		c.set(entry.Key, entry.Response, entry.TTL)
+	}
+}
+
+func (c *MemoryCache) set(key string, response *ValidateResponse, ttl time.Duration) {
+	entry := &memoryCacheEntry{key: key, response: *response, expires_at: c.now().Add(ttl)}
+	if element, ok := c.entries[key]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.capacity > 0 && c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+}
+
+// Len - number of results held (including expired ones not yet evicted)
+func (c *MemoryCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.order.Len()
+}
+
+type fileCacheEntry struct {
+	Response  ValidateResponse `json:"response"`
+	ExpiresAt time.Time        `json:"expires_at"`
+}
+
+// FileCache - validation cache persisted as a JSON file, such that results
+// survive restarts. The whole file is rewritten on every Set or SetMany call
+// (ValidateBatch stores the results of a batch with a single SetMany), which
+// suits caches of moderate size.
+type FileCache struct {
+	path    string
+	mutex   sync.Mutex
+	entries map[string]fileCacheEntry
+	now     func() time.Time
+}
+
+// NewFileCache - open (or create on first write) a file-backed cache
+func NewFileCache(path_to_file string) (*FileCache, error) {
+	cache := &FileCache{path: path_to_file, entries: make(map[string]fileCacheEntry), now: time.Now}
+	contents, error_ := os.ReadFile(path_to_file)
+	if errors.Is(error_, os.ErrNotExist) {
+		return cache, nil
+	}
+	if error_ != nil {
+		return nil, error_
+	}
+	if len(strings.TrimSpace(string(contents))) > 0 {
+		error_ = json.Unmarshal(contents, &cache.entries)
+		if error_ != nil {
+			return nil, errors.New("could not decode cache file: " + error_.Error())
+		}
+	}
+	return cache, nil
+}
+
+// Get - see ValidationCache
+func (c *FileCache) Get(key string) (*ValidateResponse, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || !c.now().Before(entry.ExpiresAt) {
+		return nil, false
+	}
+	return copyValidateResponse(&entry.Response), true
+}
+
+// Set - see ValidationCache; write errors are ignored, as the result
+// remains cached in memory (use Flush to surface them)
+func (c *FileCache) Set(key string, response *ValidateResponse, ttl time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries[key] = fileCacheEntry{Response: *response, ExpiresAt: c.now().Add(ttl)}
+	c.save()
+}
+
+// SetMany - see BatchValidationCache; the file is written once
+func (c *FileCache) SetMany(entries []CacheEntry) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := c.now()
+	for _, entry := range entries {
+		c.entries[entry.Key] = fileCacheEntry{Response: *entry.Response, ExpiresAt: now.Add(entry.TTL)}
+	}
+	c.save()
+}
+
+// Flush - drop expired results and write the cache to its file
+func (c *FileCache) Flush() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.save()
+}
+
+// save - drop expired entries and write the others to the cache file
+func (c *FileCache) save() error {
+	now := c.now()
+	for key, entry := range c.entries {
+		if !now.Before(entry.ExpiresAt) {
+			delete(c.entries, key)
+		}
+	}
+	contents, error_ := json.Marshal(c.entries)
+	if error_ != nil {
+		return error_
+	}
+	return writeFileAtomic(c.path, contents)
+}
+
+// writeFileAtomic - write the contents into a temporary file and move it over
+// the given path, such that a crash never leaves a truncated file behind
+func writeFileAtomic(path_to_file string, contents []byte) error {
+	temporary_file, error_ := os.CreateTemp(filepath.Dir(path_to_file), filepath.Base(path_to_file)+".*.tmp")
+	if error_ != nil {
+		return error_
+	}
+	_, error_ = temporary_file.Write(contents)
+	if close_error := temporary_file.Close(); error_ == nil {
+		error_ = close_error
+	}
+	if error_ != nil {
+		os.Remove(temporary_file.Name())
+		return error_
+	}
+	return os.Rename(temporary_file.Name(), path_to_file)
+}
@@ -0,0 +1,213 @@
+package zerobouncego
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryCacheLRU(t *testing.T) {
+	cache := NewMemoryCache(2)
+	cache.Set("a", &ValidateResponse{Address: "a", Status: S_VALID}, time.Hour)
+	cache.Set("b", &ValidateResponse{Address: "b", Status: S_VALID}, time.Hour)
+
+	// "a" becomes the most recently used, so "b" is evicted
+	_, ok := cache.Get("a")
+	assert.True(t, ok)
+	cache.Set("c", &ValidateResponse{Address: "c", Status: S_VALID}, time.Hour)
+	assert.Equal(t, 2, cache.Len())
+	_, ok = cache.Get("b")
+	assert.False(t, ok)
+
+	// returned responses are copies
+	response, ok := cache.Get("a")
+	assert.True(t, ok)
+	response.Status = S_INVALID
+	response, _ = cache.Get("a")
+	assert.Equal(t, S_VALID, response.Status)
+}
+
+func TestMemoryCacheExpiry(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := NewMemoryCache(0)
+	cache.now = func() time.Time { return now }
+
+	cache.Set("a", &ValidateResponse{Status: S_UNKNOWN}, time.Hour)
+	_, ok := cache.Get("a")
+	assert.True(t, ok)
+
+	now = now.Add(time.Hour)
+	_, ok = cache.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestFileCachePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	cache, error_ := NewFileCache(path)
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	cache.Set("valid@example.com", mockedResponse(t, "valid@example.com"), time.Hour)
+	cache.Set("expired@example.com", &ValidateResponse{Status: S_UNKNOWN}, -time.Second)
+
+	reopened, error_ := NewFileCache(path)
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	response, ok := reopened.Get("valid@example.com")
+	assert.True(t, ok)
+	assert.Equal(t, S_VALID, response.Status)
+	assert.Equal(t, "zero", response.Firstname.String)
+	_, ok = reopened.Get("expired@example.com")
+	assert.False(t, ok)
+	assert.Len(t, reopened.entries, 1)
+
+	reopened.SetMany([]CacheEntry{
+		{Key: "a@example.com", Response: &ValidateResponse{Status: S_VALID}, TTL: time.Hour},
+		{Key: "b@example.com|" + SANDBOX_IP, Response: &ValidateResponse{Status: S_INVALID}, TTL: time.Hour},
+	})
+	reopened, _ = NewFileCache(path)
+	response, ok = reopened.Get("b@example.com|" + SANDBOX_IP)
+	assert.True(t, ok)
+	assert.Equal(t, S_INVALID, response.Status)
+	assert.Len(t, reopened.entries, 3)
+}
+
+func TestCacheTTL(t *testing.T) {
+	assert.Equal(t, 30*24*time.Hour, CacheTTL(S_VALID))
+	assert.Equal(t, time.Hour, CacheTTL(S_UNKNOWN))
+	assert.Equal(t, CACHE_DEFAULT_TTL, CacheTTL("some_future_status"))
+	assert.Equal(t, "user@xn--bcher-kva.de", CacheKey(" User@Bücher.de", ""))
+	assert.Equal(t, "user@example.com|203.0.113.7", CacheKey("User@example.com", " 203.0.113.7"))
+}
+
+// useMemoryCache - enable a fresh validation cache for the duration of a test
+func useMemoryCache(t *testing.T) *MemoryCache {
+	cache := NewMemoryCache(100)
+	VALIDATION_CACHE = cache
+	ResetValidationCacheStats()
+	t.Cleanup(func() { VALIDATION_CACHE = nil })
+	return cache
+}
+
+func TestValidateUsesCache(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	mockValidateRequest()
+	useMemoryCache(t)
+
+	response, error_ := Validate("valid@example.com", SANDBOX_IP)
+	assert.Nil(t, error_)
+	assert.Equal(t, S_VALID, response.Status)
+
+	response, error_ = Validate(" VALID@example.com", SANDBOX_IP)
+	assert.Nil(t, error_)
+	assert.Equal(t, S_VALID, response.Status)
+	assert.Equal(t, " VALID@example.com", response.SubmittedAddress)
+
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 1}, ValidationCacheStats())
+	assert.Equal(t, 0.5, ValidationCacheStats().HitRatio())
+}
+
+func TestValidateCacheSkipsDisabledStatus(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	mockValidateRequest()
+	useMemoryCache(t)
+	CACHE_TTL_BY_STATUS[S_UNKNOWN] = 0
+	defer func() { CACHE_TTL_BY_STATUS[S_UNKNOWN] = time.Hour }()
+
+	Validate("unknown@example.com", SANDBOX_IP)
+	Validate("unknown@example.com", SANDBOX_IP)
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+}
+
+func TestValidateBatchUsesCache(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	mockValidateRequest()
+	mockBatchValidateRequest()
+	useMemoryCache(t)
+
+	_, error_ := Validate("valid@example.com", SANDBOX_IP)
+	assert.Nil(t, error_)
+
+	response, error_ := ValidateBatch([]EmailToValidate{{EmailAddress: "valid@example.com", IPAddress: SANDBOX_IP}, {EmailAddress: "toxic@example.com"}})
+	assert.Nil(t, error_)
+	assert.Len(t, response.EmailBatch, 2)
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+
+	// both addresses are now cached: no further request
+	response, error_ = ValidateBatch([]EmailToValidate{{EmailAddress: "valid@example.com", IPAddress: SANDBOX_IP}, {EmailAddress: "toxic@example.com"}})
+	assert.Nil(t, error_)
+	assert.Len(t, response.EmailBatch, 2)
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+	assert.Equal(t, CacheStats{Hits: 3, Misses: 2}, ValidationCacheStats())
+
+	// results depend on the IP address, which is part of the key
+	_, error_ = Validate("valid@example.com", "198.51.100.1")
+	assert.Nil(t, error_)
+	assert.Equal(t, 3, httpmock.GetTotalCallCount())
+}
+
+// countingCache - memory cache counting its Set and SetMany calls
+type countingCache struct {
+	*MemoryCache
+	sets, set_manys int
+}
+
+func (c *countingCache) Set(key string, response *ValidateResponse, ttl time.Duration) {
+	c.sets++
+	c.MemoryCache.Set(key, response, ttl)
+}
+
+func (c *countingCache) SetMany(entries []CacheEntry) {
+	c.set_manys++
+	c.MemoryCache.SetMany(entries)
+}
+
+func TestValidateBatchStoresResultsAtOnce(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	mockBatchValidateRequest()
+	cache := &countingCache{MemoryCache: NewMemoryCache(100)}
+	VALIDATION_CACHE = cache
+	defer func() { VALIDATION_CACHE = nil }()
+
+	_, error_ := ValidateBatch([]EmailToValidate{{EmailAddress: "valid@example.com"}, {EmailAddress: "toxic@example.com"}})
+	assert.Nil(t, error_)
+	assert.Equal(t, 0, cache.sets)
+	assert.Equal(t, 1, cache.set_manys)
+	assert.Equal(t, 2, cache.Len())
+}
+
+func TestValidateBatchCachesSentAddress(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	// the API returns the address in another form than the one sent
+	httpmock.RegisterResponder("POST", `=~^(.*)`+ENDPOINT_BATCH_VALIDATE+`(.*)\z`,
+		httpmock.NewStringResponder(200, `{"email_batch": [{"address": "User@B\u00fccher.de", "status": "valid"}], "errors": []}`))
+	cache := useMemoryCache(t)
+
+	emails := []EmailToValidate{{EmailAddress: "user@xn--bcher-kva.de", IPAddress: SANDBOX_IP}}
+	response, error_ := ValidateBatch(emails)
+	assert.Nil(t, error_)
+	assert.Len(t, response.EmailBatch, 1)
+	_, ok := cache.Get(CacheKey("user@xn--bcher-kva.de", SANDBOX_IP))
+	assert.True(t, ok)
+
+	response, error_ = ValidateBatch(emails)
+	assert.Nil(t, error_)
+	assert.Equal(t, S_VALID, response.EmailBatch[0].Status)
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+}
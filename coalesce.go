@@ -0,0 +1,88 @@
+package zerobouncego
+
+import (
+	"fmt"
+	"sync"
+)
+
+// COALESCE_VALIDATE - when enabled (default), concurrent Validate and
+// ValidateWithTimeout calls for the same normalized address, IP address and
+// timeout share a single API request (and a single credit); every caller gets
+// its own copy of the shared response or error
+var COALESCE_VALIDATE = true
+
+type validateCall struct {
+	done     chan struct{}
+	response *ValidateResponse
+	error_   error
+}
+
+// validateGroup - singleflight style de-duplication of in-flight validations
+type validateGroup struct {
+	mutex sync.Mutex
+	calls map[string]*validateCall
+}
+
+var inflightValidations = &validateGroup{calls: make(map[string]*validateCall)}
+
+// do - run `request` unless a call with the same key is already in flight, in
+// which case wait for its outcome; `shared` reports the latter. Should
+// `request` panic, the waiting callers get an error and the panic goes on in
+// the caller running it.
+func (g *validateGroup) do(key string, request func() (*ValidateResponse, error)) (response *ValidateResponse, error_ error, shared bool) {
+	g.mutex.Lock()
+	if call, ok := g.calls[key]; ok {
+		g.mutex.Unlock()
+		<-call.done
+		return copyResponseOrNil(call.response), call.error_, true
+	}
+	call := &validateCall{done: make(chan struct{})}
+	g.calls[key] = call
+	g.mutex.Unlock()
+
+	defer func() {
+		recovered := recover()
+		if recovered != nil {
+			call.response, call.error_ = nil, fmt.Errorf("validation request panicked: %v", recovered)
+		}
+		g.mutex.Lock()
+		delete(g.calls, key)
+		g.mutex.Unlock()
+		close(call.done)
+		if recovered != nil {
+			panic(recovered)
+		}
+	}()
+	call.response, call.error_ = request()
+	return copyResponseOrNil(call.response), call.error_, false
+}
+
+// validateCallKey - calls sharing a key share their request; as for the
+// cache, the IP address is part of it
+func validateCallKey(email, IPAddress, timeout string) string {
+	return CacheKey(email, IPAddress) + "\x00" + timeout
+}
+
+func copyResponseOrNil(response *ValidateResponse) *ValidateResponse {
+	if response == nil {
+		return nil
+	}
+	return copyValidateResponse(response)
+}
+
+// coalescedValidateRequest - perform a /validate request, sharing it with
+// concurrent calls for the same address when `COALESCE_VALIDATE` is set
+func coalescedValidateRequest(email, IPAddress, timeout string) (*ValidateResponse, error) {
+	request := func() (*ValidateResponse, error) {
+		response, error_ := validateRequest(email, IPAddress, timeout)
+		if error_ == nil {
+			cacheValidateResponse(email, IPAddress, response)
+		}
+		return response, error_
+	}
+	if !COALESCE_VALIDATE {
+		return request()
+	}
+	response, error_, _ := inflightValidations.do(validateCallKey(email, IPAddress, timeout), request)
+	return response, error_
+}
@@ -0,0 +1,165 @@
+package zerobouncego
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// coalesce_join_delay - time left to the callers started while a request is
+// in flight to join it, before the request is released
+const coalesce_join_delay = 50 * time.Millisecond
+
+// mockBlockingValidateRequest - mock GET/validate such that responses are only
+// sent once `release` is closed; answers with the given error if not nil.
+// Returns a channel receiving a value as each request comes in.
+func mockBlockingValidateRequest(release chan struct{}, response_error error) chan struct{} {
+	started := make(chan struct{}, 100)
+	httpmock.RegisterResponder("GET", `=~^(.*)`+ENDPOINT_VALIDATE+`(.*)\z`,
+		func(req *http.Request) (*http.Response, error) {
+			started <- struct{}{}
+			<-release
+			if response_error != nil {
+				return nil, response_error
+			}
+			email := req.URL.Query().Get("email")
+			return httpmock.NewStringResponse(200, MOCK_VALIDATE_RESPONSE[email]), nil
+		},
+	)
+	return started
+}
+
+// runConcurrentValidations - start a validation, then `count`-1 others of the
+// same address once its request is in flight, and release the mocked request
+// once they had the time to join it
+func runConcurrentValidations(t *testing.T, count int, release, started chan struct{}) ([]*ValidateResponse, []error) {
+	responses := make([]*ValidateResponse, count)
+	errors_ := make([]error, count)
+
+	var wait_group, joining sync.WaitGroup
+	validate := func(index int) {
+		defer wait_group.Done()
+		responses[index], errors_[index] = Validate("valid@example.com", SANDBOX_IP)
+	}
+	wait_group.Add(1)
+	go validate(0)
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the first validation did not send its request")
+	}
+
+	for index := 1; index < count; index++ {
+		wait_group.Add(1)
+		joining.Add(1)
+		go func(index int) {
+			joining.Done()
+			validate(index)
+		}(index)
+	}
+	joining.Wait()
+	time.Sleep(coalesce_join_delay)
+	close(release)
+	wait_group.Wait()
+	return responses, errors_
+}
+
+func TestValidateCoalescesConcurrentCalls(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	release := make(chan struct{})
+	started := mockBlockingValidateRequest(release, nil)
+
+	responses, errors_ := runConcurrentValidations(t, 10, release, started)
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+	for index := range responses {
+		assert.Nil(t, errors_[index])
+		assert.Equal(t, S_VALID, responses[index].Status)
+	}
+	// every caller owns its response
+	assert.NotSame(t, responses[0], responses[1])
+	assert.Empty(t, inflightValidations.calls)
+}
+
+func TestValidateCoalescesErrors(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	release := make(chan struct{})
+	started := mockBlockingValidateRequest(release, errors.New(sample_error_message))
+
+	_, errors_ := runConcurrentValidations(t, 5, release, started)
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+	for _, error_ := range errors_ {
+		assert.NotNil(t, error_)
+		assert.Contains(t, error_.Error(), sample_error_message)
+	}
+}
+
+func TestValidateWithoutCoalescing(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	mockValidateRequest()
+	COALESCE_VALIDATE = false
+	defer func() { COALESCE_VALIDATE = true }()
+
+	var wait_group sync.WaitGroup
+	for index := 0; index < 3; index++ {
+		wait_group.Add(1)
+		go func() {
+			defer wait_group.Done()
+			Validate("valid@example.com", SANDBOX_IP)
+		}()
+	}
+	wait_group.Wait()
+	assert.Equal(t, 3, httpmock.GetTotalCallCount())
+}
+
+func TestValidateGroupPanic(t *testing.T) {
+	group := &validateGroup{calls: make(map[string]*validateCall)}
+	started, release := make(chan struct{}), make(chan struct{})
+
+	var recovered interface{}
+	leader_done := make(chan struct{})
+	go func() {
+		defer close(leader_done)
+		defer func() { recovered = recover() }()
+		group.do("key", func() (*ValidateResponse, error) {
+			close(started)
+			<-release
+			panic("boom")
+		})
+	}()
+	<-started
+
+	var response *ValidateResponse
+	var error_ error
+	var shared bool
+	follower_done := make(chan struct{})
+	go func() {
+		defer close(follower_done)
+		response, error_, shared = group.do("key", func() (*ValidateResponse, error) {
+			return &ValidateResponse{Status: S_VALID}, nil
+		})
+	}()
+	time.Sleep(coalesce_join_delay)
+	close(release)
+	<-leader_done
+	<-follower_done
+
+	// the panic goes on in the leader, the follower gets an error
+	assert.Equal(t, "boom", recovered)
+	assert.True(t, shared)
+	assert.Nil(t, response)
+	if assert.NotNil(t, error_) {
+		assert.Contains(t, error_.Error(), "panicked")
+	}
+	assert.Empty(t, group.calls)
+}
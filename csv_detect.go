@@ -0,0 +1,279 @@
+package zerobouncego
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// CSV_DETECT_SAMPLE_ROWS - number of rows, after the first one, inspected by
+// DetectCsvColumns
+var CSV_DETECT_SAMPLE_ROWS = 100
+
+// what a column guess is based on
+const (
+	CsvDetectSourceHeader = "header"
+	CsvDetectSourceData   = "data"
+)
+
+// CsvColumnGuess - column guessed for a CsvFile field
+type CsvColumnGuess struct {
+	// Column is 1-based, as in CsvFile
+	Column int
+	// Header is the header of the column ("" without header row)
+	Header string
+	// Source is CsvDetectSourceHeader or CsvDetectSourceData
+	Source string
+}
+
+// CsvColumnMapping - header row and columns guessed for a csv file
+type CsvColumnMapping struct {
+	HasHeaderRow bool
+	// Header is the header row (nil without one)
+	Header []string
+	// SampleRows is the number of data rows inspected
+	SampleRows int
+	// Columns holds the guessed columns, keyed as in CsvFile.ColumnsMapping
+	// (eg: "email_address_column"); fields without guess are missing
+	Columns map[string]CsvColumnGuess
+}
+
+// Apply - set the header flag and the columns of a csv file from the mapping,
+// clearing the columns without guess
+func (c *CsvColumnMapping) Apply(csv_file *CsvFile) {
+	csv_file.HasHeaderRow = c.HasHeaderRow
+	csv_file.EmailAddressColumn = c.Columns["email_address_column"].Column
+	csv_file.FirstNameColumn = c.Columns["first_name_column"].Column
+	csv_file.LastNameColumn = c.Columns["last_name_column"].Column
+	csv_file.GenderColumn = c.Columns["gender_column"].Column
+	csv_file.IpAddressColumn = c.Columns["ip_address_column"].Column
+}
+
+// csvHeaderAliases - headers (lowercased, letters and digits only) naming the
+// column of each field
+var csvHeaderAliases = map[string][]string{
+	"email_address_column": {"email", "emailaddress", "mail", "mailaddress", "emailid", "emails"},
+	"first_name_column":    {"firstname", "fname", "first", "givenname", "forename", "prenom"},
+	"last_name_column":     {"lastname", "lname", "last", "surname", "familyname", "nom"},
+	"gender_column":        {"gender", "sex"},
+	"ip_address_column":    {"ip", "ipaddress", "ipaddr", "signupip", "clientip", "userip"},
+}
+
+// csvHeaderFields - order in which header aliases are looked up
+var csvHeaderFields = []string{"email_address_column", "first_name_column", "last_name_column", "gender_column", "ip_address_column"}
+
+// csvGenderValues - cell values (lowercased) of a gender column
+var csvGenderValues = map[string]bool{"m": true, "f": true, "male": true, "female": true, "man": true, "woman": true}
+
+// csvHeaderKey - lowercased header with letters and digits only
+func csvHeaderKey(header string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, header)
+}
+
+// DetectCsvColumns - guess whether a csv file has a header row, and which
+// columns hold the email address, first and last name, gender and IP
+// address, from its header (when matching known names, such as "Email" or
+// "first_name") and from the values of its first CSV_DETECT_SAMPLE_ROWS rows.
+// Returns a copy of `csv_file` with the mapping applied, whose contents still
+// start with the rows read: seekable contents (such as an *os.File) are
+// rewound and kept as they are, others are wrapped, keeping their Close
+// method if any. Fails if no email column is found.
+func DetectCsvColumns(csv_file CsvFile) (*CsvFile, *CsvColumnMapping, error) {
+	if csv_file.File == nil {
+		return nil, nil, errors.New("csv file has no contents")
+	}
+	seeker, seekable := csv_file.File.(io.Seeker)
+	start := int64(0)
+	if seekable {
+		var error_ error
+		start, error_ = seeker.Seek(0, io.SeekCurrent)
+		seekable = error_ == nil
+	}
+	consumed := &bytes.Buffer{}
+	var reader io.Reader = csv_file.File
+	if !seekable {
+		reader = io.TeeReader(csv_file.File, consumed)
+	}
+	csv_reader := csv.NewReader(reader)
+	csv_reader.FieldsPerRecord = -1
+	csv_reader.LazyQuotes = true
+	var records [][]string
+	for len(records) <= CSV_DETECT_SAMPLE_ROWS {
+		record, error_ := csv_reader.Read()
+		if error_ == io.EOF {
+			break
+		}
+		if error_ != nil {
+			return nil, nil, errors.New("error reading from csv file: " + error_.Error())
+		}
+		records = append(records, record)
+	}
+	if len(records) == 0 {
+		return nil, nil, errors.New("csv file is empty")
+	}
+	records[0][0] = strings.TrimPrefix(records[0][0], "\ufeff")
+
+	mapping := &CsvColumnMapping{Columns: make(map[string]CsvColumnGuess)}
+	header_guesses := guessHeaderColumns(records[0])
+	first_row_has_email := false
+	for _, value := range records[0] {
+		first_row_has_email = first_row_has_email || PrecheckEmail(value).Valid
+	}
+	_, email_found := header_guesses["email_address_column"]
+	if !email_found && len(records) > 1 {
+		email_found = guessDataColumn(records[1:], nil, isEmailCell, 0.5) > 0
+	}
+	mapping.HasHeaderRow = !first_row_has_email && (len(header_guesses) > 0 || email_found)
+
+	rows := records
+	if mapping.HasHeaderRow {
+		mapping.Header = records[0]
+		mapping.Columns = header_guesses
+		rows = records[1:]
+	}
+	mapping.SampleRows = len(rows)
+
+	for _, field := range []struct {
+		key       string
+		matches   func(value string) bool
+		min_ratio float64
+	}{
+		{"email_address_column", isEmailCell, 0.5},
+		{"ip_address_column", isIpAddressCell, 0.5},
+		{"gender_column", isGenderCell, 0.8},
+	} {
+		if _, found := mapping.Columns[field.key]; found {
+			continue
+		}
+		taken := make(map[int]bool)
+		for _, guess := range mapping.Columns {
+			taken[guess.Column] = true
+		}
+		if column := guessDataColumn(rows, taken, field.matches, field.min_ratio); column > 0 {
+			guess := CsvColumnGuess{Column: column, Source: CsvDetectSourceData}
+			if mapping.HasHeaderRow && column <= len(mapping.Header) {
+				guess.Header = mapping.Header[column-1]
+			}
+			mapping.Columns[field.key] = guess
+		}
+	}
+	if _, found := mapping.Columns["email_address_column"]; !found {
+		return nil, mapping, errors.New("could not detect the email address column")
+	}
+
+	detected_file := csv_file
+	if seekable {
+		if _, error_ := seeker.Seek(start, io.SeekStart); error_ != nil {
+			return nil, mapping, errors.New("error rewinding csv file: " + error_.Error())
+		}
+	} else if closer, ok := csv_file.File.(io.Closer); ok {
+		detected_file.File = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(consumed, csv_file.File), closer}
+	} else {
+		detected_file.File = io.MultiReader(consumed, csv_file.File)
+	}
+	mapping.Apply(&detected_file)
+	return &detected_file, mapping, nil
+}
+
+// ImportCsvFileDetect - import a file to be uploaded, detecting its header
+// row and columns (see DetectCsvColumns); the contents of the returned
+// CsvFile are the opened *os.File, to be closed by the caller
+func ImportCsvFileDetect(path_to_file string) (*CsvFile, *CsvColumnMapping, error) {
+	file, error_ := os.Open(path_to_file)
+	if error_ != nil {
+		return nil, nil, error_
+	}
+	csv_file, mapping, error_ := DetectCsvColumns(CsvFile{File: file, FileName: file.Name()})
+	if error_ != nil {
+		file.Close()
+		return nil, mapping, error_
+	}
+	return csv_file, mapping, nil
+}
+
+// guessHeaderColumns - columns whose header is a known name of a field; known
+// names are looked up in every header before the email column is searched
+// among the headers merely containing "email" (such as "Work Email" or
+// "email_1"), so that "Email" wins over an earlier "Email Opt In"
+func guessHeaderColumns(header []string) map[string]CsvColumnGuess {
+	guesses := make(map[string]CsvColumnGuess)
+	taken := make(map[int]bool)
+	for _, exact := range []bool{true, false} {
+		for _, field := range csvHeaderFields {
+			if _, found := guesses[field]; found || !exact && field != "email_address_column" {
+				continue
+			}
+			for index, name := range header {
+				key := csvHeaderKey(name)
+				matches := containsFold(csvHeaderAliases[field], key)
+				if !exact {
+					matches = strings.Contains(key, "email")
+				}
+				if matches && !taken[index] {
+					guesses[field] = CsvColumnGuess{Column: index + 1, Header: name, Source: CsvDetectSourceHeader}
+					taken[index] = true
+					break
+				}
+			}
+		}
+	}
+	return guesses
+}
+
+// guessDataColumn - 1-based column, among those not taken, with the highest
+// ratio (at least `min_ratio`) of non-empty cells matching; 0 if none
+func guessDataColumn(rows [][]string, taken map[int]bool, matches func(value string) bool, min_ratio float64) int {
+	best_column, best_ratio := 0, 0.0
+	for index := 0; ; index++ {
+		filled, matched, exists := 0, 0, false
+		for _, row := range rows {
+			if index >= len(row) {
+				continue
+			}
+			exists = true
+			value := strings.TrimSpace(row[index])
+			if value == "" {
+				continue
+			}
+			filled++
+			if matches(value) {
+				matched++
+			}
+		}
+		if !exists {
+			return best_column
+		}
+		if filled == 0 || taken[index+1] {
+			continue
+		}
+		ratio := float64(matched) / float64(filled)
+		if ratio >= min_ratio && ratio > best_ratio {
+			best_column, best_ratio = index+1, ratio
+		}
+	}
+}
+
+func isEmailCell(value string) bool {
+	return PrecheckEmail(value).Valid
+}
+
+func isIpAddressCell(value string) bool {
+	return net.ParseIP(value) != nil
+}
+
+func isGenderCell(value string) bool {
+	return csvGenderValues[strings.ToLower(value)]
+}
@@ -0,0 +1,117 @@
+package zerobouncego
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectCsvColumnsFromHeader(t *testing.T) {
+	contents := "\ufeffID,First Name,last_name,Work E-mail,Sex,Signup IP\n" +
+		"1,Jane,Doe,jane@example.com,F,203.0.113.7\n" +
+		"2,John,Doe,john@example.com,M,2001:db8::1\n"
+	csv_file, mapping, error_ := DetectCsvColumns(CsvFile{File: strings.NewReader(contents), FileName: "contacts.csv"})
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	assert.True(t, mapping.HasHeaderRow)
+	assert.Equal(t, []string{"ID", "First Name", "last_name", "Work E-mail", "Sex", "Signup IP"}, mapping.Header)
+	assert.Equal(t, 2, mapping.SampleRows)
+	assert.Equal(t, CsvColumnGuess{Column: 4, Header: "Work E-mail", Source: CsvDetectSourceHeader}, mapping.Columns["email_address_column"])
+	assert.Equal(t, map[string]int{
+		"email_address_column": 4, "first_name_column": 2, "last_name_column": 3, "gender_column": 5, "ip_address_column": 6,
+	}, csv_file.ColumnsMapping())
+	assert.True(t, csv_file.HasHeaderRow)
+	assert.Equal(t, "contacts.csv", csv_file.FileName)
+
+	// the rows read are still in the contents
+	read, _ := io.ReadAll(csv_file.File)
+	assert.Equal(t, contents, string(read))
+}
+
+func TestGuessHeaderColumns(t *testing.T) {
+	guesses := guessHeaderColumns([]string{"Email Opt In", "Email", "First Name"})
+	assert.Equal(t, CsvColumnGuess{Column: 2, Header: "Email", Source: CsvDetectSourceHeader}, guesses["email_address_column"])
+	assert.Equal(t, 3, guesses["first_name_column"].Column)
+
+	// without exact name, the first header containing "email"
+	guesses = guessHeaderColumns([]string{"Name", "Work Email", "Personal Email"})
+	assert.Equal(t, 2, guesses["email_address_column"].Column)
+}
+
+func TestDetectCsvColumnsKeepsCloser(t *testing.T) {
+	contents := "email\njane@example.com\n"
+	closer := &bufferCloser{}
+	closer.WriteString(contents)
+	csv_file, _, error_ := DetectCsvColumns(CsvFile{File: closer})
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	wrapped, ok := csv_file.File.(io.ReadCloser)
+	if assert.True(t, ok) {
+		read, _ := io.ReadAll(wrapped)
+		assert.Equal(t, contents, string(read))
+		wrapped.Close()
+		assert.True(t, closer.closed)
+	}
+}
+
+func TestDetectCsvColumnsFromData(t *testing.T) {
+	contents := "Jane,female,jane@example.com,198.51.100.1\n" +
+		"John,male,,198.51.100.2\n" +
+		"Jim,male,not an address,\n" +
+		"Joe,male,joe@example.com,198.51.100.4\n"
+	csv_file, mapping, error_ := DetectCsvColumns(CsvFile{File: strings.NewReader(contents)})
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	assert.False(t, mapping.HasHeaderRow)
+	assert.Nil(t, mapping.Header)
+	assert.Equal(t, 4, mapping.SampleRows)
+	assert.Equal(t, CsvColumnGuess{Column: 3, Source: CsvDetectSourceData}, mapping.Columns["email_address_column"])
+	assert.Equal(t, map[string]int{"email_address_column": 3, "gender_column": 2, "ip_address_column": 4}, csv_file.ColumnsMapping())
+
+	// header without known names
+	_, mapping, error_ = DetectCsvColumns(CsvFile{File: strings.NewReader("Contact,Company\njane@example.com,ACME\n")})
+	assert.Nil(t, error_)
+	assert.True(t, mapping.HasHeaderRow)
+	assert.Equal(t, CsvColumnGuess{Column: 1, Header: "Contact", Source: CsvDetectSourceData}, mapping.Columns["email_address_column"])
+}
+
+func TestDetectCsvColumnsErrors(t *testing.T) {
+	_, _, error_ := DetectCsvColumns(CsvFile{File: strings.NewReader("")})
+	assert.NotNil(t, error_)
+
+	_, mapping, error_ := DetectCsvColumns(CsvFile{File: strings.NewReader("Name,Company\nJane,ACME\n")})
+	assert.NotNil(t, error_)
+	assert.False(t, mapping.HasHeaderRow)
+
+	_, _, error_ = DetectCsvColumns(CsvFile{})
+	assert.NotNil(t, error_)
+}
+
+func TestImportCsvFileDetect(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "contacts.csv")
+	os.WriteFile(path, []byte("email,fname\njane@example.com,Jane\n"), 0644)
+	csv_file, _, error_ := ImportCsvFileDetect(path)
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	assert.Equal(t, 1, csv_file.EmailAddressColumn)
+	assert.Equal(t, 2, csv_file.FirstNameColumn)
+
+	// the opened file itself, rewound
+	file, ok := csv_file.File.(*os.File)
+	if assert.True(t, ok) {
+		read, _ := io.ReadAll(file)
+		assert.Equal(t, "email,fname\njane@example.com,Jane\n", string(read))
+		assert.Nil(t, file.Close())
+	}
+
+	_, _, error_ = ImportCsvFileDetect(filepath.Join(t.TempDir(), "missing.csv"))
+	assert.NotNil(t, error_)
+}
@@ -0,0 +1,292 @@
+package zerobouncego
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// CSV_LINT_MAX_ISSUES - number of issues listed by LintCsvFile; further ones
+// are only counted
+var CSV_LINT_MAX_ISSUES = 100
+
+// kinds of issues reported by LintCsvFile
+const (
+	CsvIssueBOM              = "bom"
+	CsvIssueInvalidEncoding  = "invalid_encoding"
+	CsvIssueMixedLineEndings = "mixed_line_endings"
+	CsvIssueDelimiter        = "delimiter"
+	CsvIssueQuotedNewline    = "quoted_newline"
+	CsvIssueColumnOutOfRange = "column_out_of_range"
+	CsvIssueMissingEmail     = "missing_email"
+	CsvIssueInvalidEmail     = "invalid_email"
+	CsvIssueDuplicateEmail   = "duplicate_email"
+)
+
+// csvDelimiterCandidates - delimiters detectCsvDelimiter looks for
+const csvDelimiterCandidates = ",;\t|"
+
+// CsvLintIssue - problem found in a csv file
+type CsvLintIssue struct {
+	Kind string
+	// Row is the 1-based position of the row, the header row excluded; 0 for
+	// issues of the whole file
+	Row     int
+	Message string
+}
+
+// CsvLintReport - outcome of LintCsvFile
+type CsvLintReport struct {
+	// Rows is the number of rows, header excluded
+	Rows int
+	// EmptyEmails counts the rows without address, including those too short
+	// to have the email column
+	EmptyEmails   int
+	InvalidEmails int
+	// Duplicates counts the rows repeating the address of a previous row
+	Duplicates int
+	// Delimiter is the delimiter the file appears to use
+	Delimiter rune
+	// Issues lists the first CSV_LINT_MAX_ISSUES issues found
+	Issues []CsvLintIssue
+	// IssueCount is the number of issues found, listed or not
+	IssueCount int
+}
+
+// HasIssues - whether any issue was found
+func (c *CsvLintReport) HasIssues() bool {
+	return c.IssueCount > 0
+}
+
+func (c *CsvLintReport) add(kind string, row int, format string, arguments ...interface{}) {
+	c.IssueCount++
+	if len(c.Issues) < CSV_LINT_MAX_ISSUES {
+		c.Issues = append(c.Issues, CsvLintIssue{Kind: kind, Row: row, Message: fmt.Sprintf(format, arguments...)})
+	}
+}
+
+// LintCsvFile - check a csv file before a bulk submission: byte order mark,
+// encoding (invalid UTF-8 being read as Latin-1), line endings, delimiter,
+// newlines within cells, columns beyond the width of the file, and empty,
+// invalid (see PrecheckEmail) or duplicate addresses. With `repair`, a copy
+// of `csv_file` is also returned, safe to upload: UTF-8 without byte order
+// mark, comma delimited with "\n" line endings, newlines within cells
+// replaced by spaces, and holding the header (if any) and the rows with a
+// valid address, normalized, met for the first time. The whole file is read
+// in memory.
+func LintCsvFile(csv_file CsvFile, repair bool) (*CsvLintReport, *CsvFile, error) {
+	if csv_file.File == nil {
+		return nil, nil, errors.New("csv file has no contents")
+	}
+	if csv_file.EmailAddressColumn < 1 {
+		return nil, nil, fmt.Errorf("invalid email address column %d", csv_file.EmailAddressColumn)
+	}
+	contents, error_ := io.ReadAll(csv_file.File)
+	if error_ != nil {
+		return nil, nil, errors.New("error reading from csv file: " + error_.Error())
+	}
+	report := &CsvLintReport{}
+
+	if bytes.HasPrefix(contents, []byte("\ufeff")) {
+		report.add(CsvIssueBOM, 0, "the file starts with a UTF-8 byte order mark")
+		contents = contents[3:]
+	}
+	text, invalid_line := decodeCsvText(contents)
+	if invalid_line > 0 {
+		report.add(CsvIssueInvalidEncoding, 0, "the file is not valid UTF-8 (first at line %d), it was read as Latin-1", invalid_line)
+	}
+	text = lintLineEndings(text, report)
+	report.Delimiter = detectCsvDelimiter(text)
+	if report.Delimiter != ',' {
+		report.add(CsvIssueDelimiter, 0, "the file appears to be delimited by %q instead of commas", report.Delimiter)
+	}
+
+	csv_reader := csv.NewReader(strings.NewReader(text))
+	csv_reader.Comma = report.Delimiter
+	csv_reader.FieldsPerRecord = -1
+	csv_reader.LazyQuotes = true
+	output := &bytes.Buffer{}
+	csv_writer := csv.NewWriter(output)
+	email_index := csv_file.EmailAddressColumn - 1
+	seen := make(map[string]int)
+	width := 0
+	is_header := csv_file.HasHeaderRow
+	for {
+		record, error_ := csv_reader.Read()
+		if error_ == io.EOF {
+			break
+		}
+		if error_ != nil {
+			return nil, nil, errors.New("error reading from csv file: " + error_.Error())
+		}
+		if len(record) > width {
+			width = len(record)
+		}
+		row := 0
+		if !is_header {
+			report.Rows++
+			row = report.Rows
+		}
+		for index, value := range record {
+			if strings.Contains(value, "\n") {
+				report.add(CsvIssueQuotedNewline, row, "column %d holds a line break", index+1)
+				record[index] = strings.Join(strings.Fields(strings.ReplaceAll(value, "\n", " ")), " ")
+			}
+		}
+		if is_header {
+			is_header = false
+			csv_writer.Write(record)
+			continue
+		}
+
+		email := ""
+		if email_index < len(record) {
+			email = strings.TrimSpace(record[email_index])
+		}
+		if email == "" {
+			report.EmptyEmails++
+			report.add(CsvIssueMissingEmail, row, "the row has no address")
+			continue
+		}
+		result := PrecheckEmail(email)
+		if !result.Valid {
+			report.InvalidEmails++
+			report.add(CsvIssueInvalidEmail, row, "%q is not a valid address (%s)", email, result.Reason)
+			continue
+		}
+		key := mergeKey(email)
+		if first_row, duplicate := seen[key]; duplicate {
+			report.Duplicates++
+			report.add(CsvIssueDuplicateEmail, row, "%s was already in row %d", email, first_row)
+			continue
+		}
+		seen[key] = row
+		record[email_index] = result.Normalized
+		csv_writer.Write(record)
+	}
+
+	columns := csv_file.ColumnsMapping()
+	names := make([]string, 0, len(columns))
+	for name := range columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if columns[name] > width {
+			report.add(CsvIssueColumnOutOfRange, 0, "%s %d is beyond the %d columns of the file", name, columns[name], width)
+		}
+	}
+
+	if !repair {
+		return report, nil, nil
+	}
+	csv_writer.Flush()
+	if error_ := csv_writer.Error(); error_ != nil {
+		return report, nil, error_
+	}
+	repaired_file := csv_file
+	repaired_file.File = output
+	return report, &repaired_file, nil
+}
+
+// decodeCsvText - contents as text, the bytes which are not valid UTF-8 being
+// read as Latin-1; also returns the line of the first of them (0 if none)
+func decodeCsvText(contents []byte) (string, int) {
+	if utf8.Valid(contents) {
+		return string(contents), 0
+	}
+	invalid_line := 0
+	builder := strings.Builder{}
+	for offset := 0; offset < len(contents); {
+		r, size := utf8.DecodeRune(contents[offset:])
+		if r == utf8.RuneError && size == 1 {
+			if invalid_line == 0 {
+				invalid_line = bytes.Count(contents[:offset], []byte("\n")) + 1
+			}
+			r = rune(contents[offset])
+		}
+		builder.WriteRune(r)
+		offset += size
+	}
+	return builder.String(), invalid_line
+}
+
+// lintLineEndings - text with "\n" record terminators, reporting mixed ones;
+// line breaks within quoted cells are left as they are
+func lintLineEndings(text string, report *CsvLintReport) string {
+	crlf, cr, lf := 0, 0, 0
+	builder := strings.Builder{}
+	builder.Grow(len(text))
+	in_quotes, field_start := false, true
+	for index := 0; index < len(text); index++ {
+		char := text[index]
+		switch {
+		case in_quotes:
+			// a doubled quote is an escaped one, and leaves the cell quoted
+			if char == '"' {
+				in_quotes = index+1 < len(text) && text[index+1] == '"'
+				if in_quotes {
+					builder.WriteByte(char)
+					index++
+				}
+			}
+		case char == '"' && field_start:
+			in_quotes = true
+		case char == '\r' && index+1 < len(text) && text[index+1] == '\n':
+			crlf++
+			index++
+			char = '\n'
+		case char == '\r':
+			cr++
+			char = '\n'
+		case char == '\n':
+			lf++
+		}
+		builder.WriteByte(char)
+		field_start = !in_quotes && (char == '\n' || strings.IndexByte(csvDelimiterCandidates, char) >= 0)
+	}
+
+	kinds := 0
+	for _, count := range []int{crlf, cr, lf} {
+		if count > 0 {
+			kinds++
+		}
+	}
+	if kinds > 1 {
+		report.add(CsvIssueMixedLineEndings, 0, "the file mixes line endings (%d CRLF, %d CR, %d LF)", crlf, cr, lf)
+	}
+	return builder.String()
+}
+
+// detectCsvDelimiter - delimiter found in the first line of the text, as
+// many times as in at least half of the following ones (lines broken within
+// quoted cells not matching); the most frequent one if several are, commas
+// by default
+func detectCsvDelimiter(text string) rune {
+	lines := strings.SplitN(text, "\n", 11)
+	if len(lines) > 10 {
+		lines = lines[:10]
+	}
+	for len(lines) > 1 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	delimiter, best := ',', 0
+	for _, candidate := range csvDelimiterCandidates {
+		count := strings.Count(lines[0], string(candidate))
+		matching := 0
+		for _, line := range lines[1:] {
+			if strings.Count(line, string(candidate)) == count {
+				matching++
+			}
+		}
+		if count > best && matching*2 >= len(lines)-1 {
+			delimiter, best = candidate, count
+		}
+	}
+	return delimiter
+}
@@ -0,0 +1,100 @@
+package zerobouncego
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func lintIssueKinds(report *CsvLintReport) []string {
+	var kinds []string
+	for _, issue := range report.Issues {
+		kinds = append(kinds, issue.Kind)
+	}
+	return kinds
+}
+
+func TestLintCsvFileClean(t *testing.T) {
+	csv_file := CsvFile{File: strings.NewReader("Name,Email\nJane,jane@example.com\nJohn,john@example.com\n"), HasHeaderRow: true, EmailAddressColumn: 2}
+	report, repaired, error_ := LintCsvFile(csv_file, false)
+	assert.Nil(t, error_)
+	assert.Nil(t, repaired)
+	assert.False(t, report.HasIssues())
+	assert.Equal(t, 2, report.Rows)
+	assert.Equal(t, ',', report.Delimiter)
+}
+
+func TestLintCsvFileRepair(t *testing.T) {
+	contents := "\xef\xbb\xbfName;Email;City\r\n" +
+		"Jane;JANE@Example.COM;\"New\r\nYork\"\r\n" +
+		"Jos\xe9;jose@example.com;Paris\n" +
+		"Jim;;Rome\n" +
+		"Joe;not an address;Oslo\n" +
+		"Jane again;jane@example.com;Lima\n"
+	csv_file := CsvFile{File: strings.NewReader(contents), HasHeaderRow: true, EmailAddressColumn: 2, FileName: "contacts.csv"}
+	report, repaired, error_ := LintCsvFile(csv_file, true)
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	assert.Equal(t, 5, report.Rows)
+	assert.Equal(t, 1, report.EmptyEmails)
+	assert.Equal(t, 1, report.InvalidEmails)
+	assert.Equal(t, 1, report.Duplicates)
+	assert.Equal(t, ';', report.Delimiter)
+	assert.Equal(t, []string{
+		CsvIssueBOM, CsvIssueInvalidEncoding, CsvIssueMixedLineEndings, CsvIssueDelimiter, CsvIssueQuotedNewline,
+		CsvIssueMissingEmail, CsvIssueInvalidEmail, CsvIssueDuplicateEmail,
+	}, lintIssueKinds(report))
+	assert.Equal(t, 8, report.IssueCount)
+	assert.Equal(t, CsvLintIssue{Kind: CsvIssueDuplicateEmail, Row: 5, Message: "jane@example.com was already in row 1"}, report.Issues[7])
+	assert.Contains(t, report.Issues[1].Message, "line 4")
+
+	if assert.NotNil(t, repaired) {
+		assert.Equal(t, "contacts.csv", repaired.FileName)
+		assert.Equal(t, 2, repaired.EmailAddressColumn)
+		cleaned, _ := io.ReadAll(repaired.File)
+		assert.Equal(t, "Name,Email,City\nJane,JANE@example.com,New York\nJosé,jose@example.com,Paris\n", string(cleaned))
+	}
+}
+
+func TestLintLineEndings(t *testing.T) {
+	// line breaks within quoted cells are kept, and do not count
+	report := &CsvLintReport{}
+	text := lintLineEndings("email,note\r\na@example.com,\"one\rtwo\"\r\nb@example.com,\"say \"\"hi\"\"\r\nthere\"\r\n", report)
+	assert.Equal(t, "email,note\na@example.com,\"one\rtwo\"\nb@example.com,\"say \"\"hi\"\"\r\nthere\"\n", text)
+	assert.False(t, report.HasIssues())
+
+	text = lintLineEndings("a\r\nb\rc\n", report)
+	assert.Equal(t, "a\nb\nc\n", text)
+	assert.Equal(t, []string{CsvIssueMixedLineEndings}, lintIssueKinds(report))
+
+	// quotes within unquoted cells do not start a quoted cell
+	report = &CsvLintReport{}
+	text = lintLineEndings("5\" disk,a@example.com\r\nb,c\r\n", report)
+	assert.Equal(t, "5\" disk,a@example.com\nb,c\n", text)
+	assert.False(t, report.HasIssues())
+}
+
+func TestLintCsvFileColumns(t *testing.T) {
+	csv_file := CsvFile{File: strings.NewReader("jane@example.com,Jane\njohn@example.com\n"), EmailAddressColumn: 3, FirstNameColumn: 2, IpAddressColumn: 4}
+	report, _, error_ := LintCsvFile(csv_file, false)
+	assert.Nil(t, error_)
+	assert.Equal(t, 2, report.EmptyEmails)
+	assert.Equal(t, []string{CsvIssueMissingEmail, CsvIssueMissingEmail, CsvIssueColumnOutOfRange, CsvIssueColumnOutOfRange}, lintIssueKinds(report))
+	assert.Equal(t, "email_address_column 3 is beyond the 2 columns of the file", report.Issues[2].Message)
+
+	default_max_issues := CSV_LINT_MAX_ISSUES
+	CSV_LINT_MAX_ISSUES = 1
+	defer func() { CSV_LINT_MAX_ISSUES = default_max_issues }()
+	csv_file.File = strings.NewReader(",\n,\n,\n")
+	report, _, _ = LintCsvFile(csv_file, false)
+	assert.Len(t, report.Issues, 1)
+	assert.Equal(t, 5, report.IssueCount)
+
+	_, _, error_ = LintCsvFile(CsvFile{File: strings.NewReader("")}, false)
+	assert.NotNil(t, error_)
+	_, _, error_ = LintCsvFile(CsvFile{EmailAddressColumn: 1}, false)
+	assert.NotNil(t, error_)
+}
@@ -0,0 +1,216 @@
+package zerobouncego
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrDuplicateSubmission - a file with the same addresses was already
+// submitted, see DUPLICATE_GUARD
+var ErrDuplicateSubmission = errors.New("duplicate file submission")
+
+// DuplicateGuard - how GenericFileSubmit treats files whose addresses were
+// already submitted to the same endpoint, according to `JOB_STORE`
+type DuplicateGuard struct {
+	// Window is how far back previous submissions are looked up (no limit
+	// when 0)
+	Window time.Duration
+	// ReuseExisting answers with the file ID of the previous submission,
+	// instead of refusing the file with a DuplicateSubmissionError
+	ReuseExisting bool
+}
+
+// DUPLICATE_GUARD - guard against submitting the same addresses twice (nil
+// disables it); it requires `JOB_STORE`, and is bypassed for files having
+// `CsvFile.ForceSubmit` set. Files are compared by their normalized email
+// column, regardless of row order and other columns; jobs that failed or
+// were deleted are not considered. Files must be seekable, unless forced.
+// Within a process, the check and the submission of the same addresses are
+// serialized, such that concurrent submissions cannot both pass the guard;
+// processes sharing a job store are not coordinated.
+var DUPLICATE_GUARD *DuplicateGuard
+
+// DuplicateSubmissionError - a file was refused as a duplicate of a previous
+// submission
+type DuplicateSubmissionError struct {
+	FileId      string
+	SubmittedAt time.Time
+}
+
+func (d *DuplicateSubmissionError) Error() string {
+	return fmt.Sprintf(
+		"%s: the same addresses were submitted as file %s on %s (set CsvFile.ForceSubmit to submit them again)",
+		ErrDuplicateSubmission, d.FileId, d.SubmittedAt.Format(DATE_TIME_FORMAT),
+	)
+}
+
+// Is - match ErrDuplicateSubmission
+func (d *DuplicateSubmissionError) Is(target error) bool {
+	return target == ErrDuplicateSubmission
+}
+
+// submissionLocks - per endpoint and digest locks, held from the duplicate
+// check until the submission is recorded
+var submissionLocks = &keyedMutex{locks: make(map[string]*keyedLock)}
+
+type keyedLock struct {
+	sync.Mutex
+	users int
+}
+
+// keyedMutex - set of mutexes created on demand, and dropped once unused
+type keyedMutex struct {
+	mutex sync.Mutex
+	locks map[string]*keyedLock
+}
+
+// lock - lock the mutex of a key, returning the function unlocking it
+func (k *keyedMutex) lock(key string) func() {
+	k.mutex.Lock()
+	lock, ok := k.locks[key]
+	if !ok {
+		lock = &keyedLock{}
+		k.locks[key] = lock
+	}
+	lock.users++
+	k.mutex.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+		k.mutex.Lock()
+		lock.users--
+		if lock.users == 0 {
+			delete(k.locks, key)
+		}
+		k.mutex.Unlock()
+	}
+}
+
+// guardSubmission - look a file up among the previous submissions to the
+// endpoint; returns the digest of its email column, to be recorded, the
+// function to call once the submission is over (the check and the
+// submission of files with the same digest being serialized) and the
+// response to answer with, if it is a duplicate to be reused
+func guardSubmission(csv_file CsvFile, endpoint string) (string, func(), *FileValidationResponse, error) {
+	no_lock := func() {}
+	guard := DUPLICATE_GUARD
+	if guard == nil {
+		return "", no_lock, nil, nil
+	}
+	store := JOB_STORE
+	if store == nil {
+		return "", no_lock, nil, errors.New("the duplicate submission guard requires a JOB_STORE")
+	}
+	if _, seekable := csv_file.File.(io.Seeker); !seekable && csv_file.ForceSubmit {
+		// nothing to check, and no digest to record without reading it twice
+		return "", no_lock, nil, nil
+	}
+	emails_sha256, error_ := EmailColumnDigest(csv_file)
+	if error_ != nil {
+		return "", no_lock, nil, error_
+	}
+	unlock := submissionLocks.lock(endpoint + "|" + emails_sha256)
+	if csv_file.ForceSubmit {
+		return emails_sha256, unlock, nil, nil
+	}
+
+	records, error_ := store.List()
+	if error_ != nil {
+		unlock()
+		return emails_sha256, no_lock, nil, error_
+	}
+	cutoff := time.Now().Add(-guard.Window)
+	for index := len(records) - 1; index >= 0; index-- {
+		record := records[index]
+		if record.EmailsSHA256 != emails_sha256 || record.Endpoint != endpoint ||
+			record.State == BulkJobStageFailed || record.State == BulkJobStageDeleted ||
+			(guard.Window > 0 && record.CreatedAt.Before(cutoff)) {
+			continue
+		}
+		unlock()
+		if !guard.ReuseExisting {
+			return emails_sha256, no_lock, nil, &DuplicateSubmissionError{FileId: record.FileId, SubmittedAt: record.CreatedAt}
+		}
+		return emails_sha256, no_lock, &FileValidationResponse{
+			Success:  true,
+			Message:  fmt.Sprintf("File already submitted as %s", record.FileId),
+			FileName: record.FileName,
+			FileId:   record.FileId,
+		}, nil
+	}
+	return emails_sha256, unlock, nil, nil
+}
+
+// EmailColumnDigest - SHA-256 (hex-encoded) of the addresses in the email
+// column of a file, normalized (see NormalizeEmail), lowercased and sorted.
+// The file must be seekable (eg: an *os.File): it is read, then rewound
+// where it was, such that it can still be uploaded without being held in
+// memory.
+func EmailColumnDigest(csv_file CsvFile) (string, error) {
+	if csv_file.File == nil {
+		return "", errors.New("csv file has no contents")
+	}
+	if csv_file.EmailAddressColumn < 1 {
+		return "", errors.New("the email address column is not set")
+	}
+	seeker, seekable := csv_file.File.(io.Seeker)
+	if !seekable {
+		return "", errors.New("the email column digest requires a seekable file (set CsvFile.ForceSubmit to skip the duplicate check)")
+	}
+	start, error_ := seeker.Seek(0, io.SeekCurrent)
+	if error_ != nil {
+		return "", errors.New("error seeking in csv file: " + error_.Error())
+	}
+
+	emails, error_ := readEmailColumn(csv_file.File, csv_file.HasHeaderRow, csv_file.EmailAddressColumn)
+	if _, seek_error := seeker.Seek(start, io.SeekStart); seek_error != nil && error_ == nil {
+		error_ = seek_error
+	}
+	if error_ != nil {
+		return "", error_
+	}
+	sort.Strings(emails)
+	hash := sha256.New()
+	for _, email := range emails {
+		io.WriteString(hash, email)
+		io.WriteString(hash, "\n")
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// readEmailColumn - normalized, lowercased addresses of a (1-based) column,
+// empty cells being skipped
+func readEmailColumn(reader io.Reader, has_header bool, column int) ([]string, error) {
+	csv_reader := csv.NewReader(reader)
+	csv_reader.FieldsPerRecord = -1
+	csv_reader.LazyQuotes = true
+	var emails []string
+	for row := 0; ; row++ {
+		record, error_ := csv_reader.Read()
+		if error_ == io.EOF {
+			return emails, nil
+		}
+		if error_ != nil {
+			return nil, errors.New("error reading from csv file: " + error_.Error())
+		}
+		if (row == 0 && has_header) || column > len(record) {
+			continue
+		}
+		email := strings.TrimSpace(record[column-1])
+		if row == 0 {
+			email = strings.TrimPrefix(email, "\ufeff")
+		}
+		if email != "" {
+			emails = append(emails, strings.ToLower(NormalizeEmail(email)))
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package zerobouncego
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// useDuplicateGuard - set `DUPLICATE_GUARD` for the test
+func useDuplicateGuard(t *testing.T, guard DuplicateGuard) {
+	DUPLICATE_GUARD = &guard
+	t.Cleanup(func() { DUPLICATE_GUARD = nil })
+}
+
+func TestEmailColumnDigest(t *testing.T) {
+	digest := func(contents string, has_header bool, column int) string {
+		value, error_ := EmailColumnDigest(CsvFile{File: strings.NewReader(contents), HasHeaderRow: has_header, EmailAddressColumn: column})
+		assert.Nil(t, error_)
+		return value
+	}
+	reference := digest("a@example.com\nb@example.com\n", false, 1)
+	assert.Equal(t, reference, digest("name,email\nB,B@EXAMPLE.COM\nA, a@Example.com \n", true, 2))
+	assert.Equal(t, reference, digest("\ufeffb@example.com,x\n\"a@example.com\",y\n\n,z\n", false, 1))
+	assert.NotEqual(t, reference, digest("a@example.com\n", false, 1))
+	assert.NotEqual(t, reference, digest("a@example.com\nc@example.com\n", false, 1))
+
+	// seekable files are rewound where they were
+	file := strings.NewReader("skipped\na@example.com\n")
+	file.Seek(8, io.SeekStart)
+	value, error_ := EmailColumnDigest(CsvFile{File: file, EmailAddressColumn: 1})
+	assert.Nil(t, error_)
+	assert.Equal(t, digest("a@example.com\n", false, 1), value)
+	contents, _ := io.ReadAll(file)
+	assert.Equal(t, "a@example.com\n", string(contents))
+
+	// others are not read into memory
+	_, error_ = EmailColumnDigest(CsvFile{File: onlyReader{strings.NewReader("a@example.com\n")}, EmailAddressColumn: 1})
+	assert.NotNil(t, error_)
+
+	_, error_ = EmailColumnDigest(CsvFile{File: strings.NewReader("a@example.com\n")})
+	assert.NotNil(t, error_)
+	_, error_ = EmailColumnDigest(CsvFile{})
+	assert.NotNil(t, error_)
+}
+
+func TestDuplicateGuardRefuses(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	store := useFileJobStore(t)
+	useDuplicateGuard(t, DuplicateGuard{Window: time.Hour})
+	mockSendFile(ENDPOINT_SCORING_SEND)
+	send_calls := "POST =~^(.*)" + ENDPOINT_SCORING_SEND + `(.*)\z`
+	csv_file := func(contents string) CsvFile {
+		return CsvFile{File: strings.NewReader(contents), FileName: "emails.csv", EmailAddressColumn: 1}
+	}
+
+	_, error_ := AiScoringFileSubmit(csv_file("a@example.com\nb@example.com\n"), false)
+	assert.Nil(t, error_)
+	record, _ := store.Get(testing_file_id)
+	assert.NotEmpty(t, record.EmailsSHA256)
+
+	response, error_ := AiScoringFileSubmit(csv_file("B@example.com\na@example.com\n"), false)
+	assert.Nil(t, response)
+	assert.True(t, errors.Is(error_, ErrDuplicateSubmission))
+	var duplicate *DuplicateSubmissionError
+	if assert.True(t, errors.As(error_, &duplicate)) {
+		assert.Equal(t, testing_file_id, duplicate.FileId)
+		assert.Contains(t, error_.Error(), testing_file_id)
+	}
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()[send_calls])
+
+	// other addresses, or forced
+	_, error_ = AiScoringFileSubmit(csv_file("c@example.com\n"), false)
+	assert.Nil(t, error_)
+	forced := csv_file("a@example.com\nb@example.com\n")
+	forced.ForceSubmit = true
+	_, error_ = AiScoringFileSubmit(forced, false)
+	assert.Nil(t, error_)
+	assert.Equal(t, 3, httpmock.GetCallCountInfo()[send_calls])
+
+	// outside of the window, or after a failure
+	record, _ = store.Get(testing_file_id)
+	record.CreatedAt = time.Now().Add(-2 * time.Hour)
+	assert.Nil(t, store.Save(*record))
+	_, error_ = AiScoringFileSubmit(csv_file("a@example.com\nb@example.com\n"), false)
+	assert.Nil(t, error_)
+	record, _ = store.Get(testing_file_id)
+	record.setState(BulkJobStageFailed, time.Now())
+	assert.Nil(t, store.Save(*record))
+	_, error_ = AiScoringFileSubmit(csv_file("a@example.com\nb@example.com\n"), false)
+	assert.Nil(t, error_)
+	assert.Equal(t, 5, httpmock.GetCallCountInfo()[send_calls])
+}
+
+func TestDuplicateGuardReusesExisting(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	store := useFileJobStore(t)
+	useDuplicateGuard(t, DuplicateGuard{ReuseExisting: true})
+	mockSendFile(ENDPOINT_FILE_SEND)
+
+	assert.Nil(t, store.Save(JobRecord{
+		FileId: "previous", Endpoint: ENDPOINT_FILE_SEND, FileName: "previous.csv", State: BulkJobStageDone,
+		EmailsSHA256: func() string {
+			value, _ := EmailColumnDigest(CsvFile{File: strings.NewReader("a@example.com\n"), EmailAddressColumn: 1})
+			return value
+		}(),
+		CreatedAt: time.Now().Add(-30 * 24 * time.Hour),
+	}))
+	response, error_ := BulkValidationSubmit(CsvFile{File: strings.NewReader("a@example.com\n"), FileName: "emails.csv", EmailAddressColumn: 1}, false)
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	assert.True(t, response.Success)
+	assert.Equal(t, "previous", response.FileId)
+	assert.Equal(t, 0, httpmock.GetCallCountInfo()["POST =~^(.*)"+ENDPOINT_FILE_SEND+`(.*)\z`])
+
+	// same addresses, other endpoint
+	mockSendFile(ENDPOINT_SCORING_SEND)
+	response, error_ = AiScoringFileSubmit(CsvFile{File: strings.NewReader("a@example.com\n"), FileName: "emails.csv", EmailAddressColumn: 1}, false)
+	assert.Nil(t, error_)
+	assert.Equal(t, testing_file_id, response.FileId)
+
+	JOB_STORE = nil
+	_, error_ = BulkValidationSubmit(CsvFile{File: strings.NewReader("a@example.com\n"), FileName: "emails.csv", EmailAddressColumn: 1}, false)
+	assert.NotNil(t, error_)
+}
+
+func TestDuplicateGuardNonSeekable(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	useFileJobStore(t)
+	useDuplicateGuard(t, DuplicateGuard{})
+	mockSendFile(ENDPOINT_FILE_SEND)
+	csv_file := CsvFile{File: onlyReader{strings.NewReader("a@example.com\n")}, FileName: "emails.csv", EmailAddressColumn: 1}
+
+	_, error_ := BulkValidationSubmit(csv_file, false)
+	assert.NotNil(t, error_)
+	assert.Equal(t, 0, httpmock.GetTotalCallCount())
+
+	csv_file.ForceSubmit = true
+	_, error_ = BulkValidationSubmit(csv_file, false)
+	assert.Nil(t, error_)
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+}
+
+func TestDuplicateGuardConcurrentSubmissions(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	useFileJobStore(t)
+	useDuplicateGuard(t, DuplicateGuard{})
+	mockSendFile(ENDPOINT_FILE_SEND)
+
+	errors_ := make([]error, 5)
+	var wait_group sync.WaitGroup
+	for index := range errors_ {
+		wait_group.Add(1)
+		go func(index int) {
+			defer wait_group.Done()
+			_, errors_[index] = BulkValidationSubmit(CsvFile{File: strings.NewReader("a@example.com\n"), FileName: "emails.csv", EmailAddressColumn: 1}, false)
+		}(index)
+	}
+	wait_group.Wait()
+
+	// a single submission passes the guard
+	duplicates := 0
+	for _, error_ := range errors_ {
+		if errors.Is(error_, ErrDuplicateSubmission) {
+			duplicates++
+		}
+	}
+	assert.Equal(t, 4, duplicates)
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+	assert.Empty(t, submissionLocks.locks)
+}
@@ -1,7 +1,6 @@
 package zerobouncego
 
 import (
-	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -29,6 +28,8 @@ type GetFileOptions struct {
 	DownloadType *string
 	// ActivityData is sent only for validation getfile (not AI scoring).
 	ActivityData *bool
+	// Progress, if set, receives the progress of the result download.
+	Progress ProgressFunc
 }
 
 // CsvFile - used for bulk validations and AI scoring
@@ -49,6 +50,10 @@ type CsvFile struct {
 	ReturnURL string
 	// AllowPhase2 is optional; sent as allow_phase_2 for bulk validation sendfile only (not AI scoring).
 	AllowPhase2 *bool
+	// Progress is optional; if set, it receives the progress of the upload.
+	Progress ProgressFunc `json:"-"`
+	// ForceSubmit bypasses DUPLICATE_GUARD for this file.
+	ForceSubmit bool `json:"-"`
 }
 
 // ColumnsMapping - function generating how columns-index mapping of the instance
@@ -82,22 +87,7 @@ func (csv_file *CsvFile) FillMultipartForm(multipart_writer *multipart.Writer, v
 	var file_form_writer io.Writer
 
 	// add the fields FIRST
-	multipart_writer.WriteField("api_key", API_KEY)
-	multipart_writer.WriteField("has_header_row", fmt.Sprintf("%v", csv_file.HasHeaderRow))
-
-	if csv_file.ReturnURL != "" {
-		multipart_writer.WriteField("return_url", csv_file.ReturnURL)
-	}
-
-	// add column-related fields
-	columns_mapping := csv_file.ColumnsMapping()
-	for column_key := range columns_mapping {
-		multipart_writer.WriteField(column_key, fmt.Sprintf("%d", columns_mapping[column_key]))
-	}
-
-	if validationSendfile && csv_file.AllowPhase2 != nil {
-		multipart_writer.WriteField("allow_phase_2", fmt.Sprintf("%t", *csv_file.AllowPhase2))
-	}
+	csv_file.writeFormFields(multipart_writer, validationSendfile)
 
 	// add the file AFTERWARDS
 	file_form_writer, error_ = multipart_writer.CreateFormFile("file", csv_file.FileName)
@@ -106,12 +96,11 @@ func (csv_file *CsvFile) FillMultipartForm(multipart_writer *multipart.Writer, v
 	}
 
 	// add file in form-data and add terminating boundary
-	contents, error_ := io.ReadAll(csv_file.File)
+	_, error_ = io.Copy(file_form_writer, csv_file.File)
 	if error_ != nil {
 		return errors.New("error reading from csv file: " + error_.Error())
 	}
 
-	file_form_writer.Write(contents)
 	error_ = multipart_writer.Close()
 	if error_ != nil {
 		return errors.New("error populating multiform with file: " + error_.Error())
@@ -119,6 +108,26 @@ func (csv_file *CsvFile) FillMultipartForm(multipart_writer *multipart.Writer, v
 	return nil
 }
 
+// writeFormFields - add the non-file fields of the form
+func (csv_file *CsvFile) writeFormFields(multipart_writer *multipart.Writer, validationSendfile bool) {
+	multipart_writer.WriteField("api_key", API_KEY)
+	multipart_writer.WriteField("has_header_row", fmt.Sprintf("%v", csv_file.HasHeaderRow))
+
+	if csv_file.ReturnURL != "" {
+		multipart_writer.WriteField("return_url", csv_file.ReturnURL)
+	}
+
+	// add column-related fields
+	columns_mapping := csv_file.ColumnsMapping()
+	for column_key := range columns_mapping {
+		multipart_writer.WriteField(column_key, fmt.Sprintf("%d", columns_mapping[column_key]))
+	}
+
+	if validationSendfile && csv_file.AllowPhase2 != nil {
+		multipart_writer.WriteField("allow_phase_2", fmt.Sprintf("%t", *csv_file.AllowPhase2))
+	}
+}
+
 // FileValidationResponse - response payload from a successful
 type FileValidationResponse struct {
 	Success  bool        `json:"success"`
@@ -187,28 +196,34 @@ func GenericFileSubmit(
 	remove_duplicate bool,
 	endpoint string,
 ) (*FileValidationResponse, error) {
-	var multipart_buffer *bytes.Buffer = &bytes.Buffer{}
 	var error_ error
 
-	// MULTI-PART FORM PREPARATION
-	multipart_writer := multipart.NewWriter(multipart_buffer)
-	multipart_writer.WriteField("remove_duplicate", fmt.Sprintf("%v", remove_duplicate))
+	url_to_access, error_ := url.JoinPath(BULK_URI, endpoint)
+	if error_ != nil {
+		return nil, error_
+	}
+
+	// DUPLICATE SUBMISSION GUARD, if enabled
+	emails_sha256, unlock, duplicate_response, error_ := guardSubmission(csv_file, endpoint)
+	if error_ != nil || duplicate_response != nil {
+		return duplicate_response, error_
+	}
+	defer unlock()
+
+	// MULTI-PART FORM PREPARATION, the file being streamed into the request
 	validationSendfile := endpoint == ENDPOINT_FILE_SEND
-	error_ = csv_file.FillMultipartForm(multipart_writer, validationSendfile)
+	upload, error_ := newMultipartUpload(csv_file, remove_duplicate, validationSendfile)
 	if error_ != nil {
 		return nil, error_
 	}
 
 	// THE ACTUAL REQUEST
-	url_to_access, error_ := url.JoinPath(BULK_URI, endpoint)
+	request, error_ := upload.request(url_to_access)
 	if error_ != nil {
 		return nil, error_
 	}
-	response_http, error_ := timedHTTPClient().Post(
-		url_to_access,
-		multipart_writer.FormDataContentType(),
-		multipart_buffer,
-	)
+	response_http, error_ := doTransferRequest(request)
+	upload.finish()
 	if error_ != nil {
 		return nil, error_
 	}
@@ -225,6 +240,12 @@ func GenericFileSubmit(
 	if error_ != nil {
 		return nil, error_
 	}
+	if response_object.Success && response_object.FileId != "" {
+		error_ = recordJobSubmission(response_object.FileId, endpoint, csv_file, remove_duplicate, upload, emails_sha256)
+		if error_ != nil {
+			return response_object, fmt.Errorf("file %s was submitted, but could not be recorded: %w", response_object.FileId, error_)
+		}
+	}
 	return response_object, nil
 }
 
@@ -268,8 +289,8 @@ func isScoringBulkEndpoint(endpoint string) bool {
 	return strings.Contains(endpoint, "/scoring/")
 }
 
-// genericResultFetch implements bulk getfile with optional v2 query params and JSON error handling.
-func genericResultFetch(file_id, endpoint string, file_writer io.Writer, opts *GetFileOptions, scoring bool) error {
+// resultFetchURL - getfile URL, with the optional v2 query parameters
+func resultFetchURL(file_id, endpoint string, opts *GetFileOptions, scoring bool) (string, error) {
 	params := url.Values{}
 	params.Set("api_key", API_KEY)
 	params.Set("file_id", file_id)
@@ -283,44 +304,36 @@ func genericResultFetch(file_id, endpoint string, file_writer io.Writer, opts *G
 	}
 
 	url_to_request, err := url.JoinPath(BULK_URI, endpoint)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s?%s", url_to_request, params.Encode()), nil
+}
+
+// genericResultFetch implements bulk getfile with optional v2 query params and JSON error handling.
+func genericResultFetch(file_id, endpoint string, file_writer io.Writer, opts *GetFileOptions, scoring bool) error {
+	url_to_request, err := resultFetchURL(file_id, endpoint, opts, scoring)
 	if err != nil {
 		return err
 	}
-	url_to_request = fmt.Sprintf("%s?%s", url_to_request, params.Encode())
-	response_http, err := timedHTTPClient().Get(url_to_request)
+	response_http, err := transferGet(url_to_request)
 	if err != nil {
 		return err
 	}
 	defer response_http.Body.Close()
 
-	body, err := io.ReadAll(response_http.Body)
-	if err != nil {
-		return errors.New("could not read response body: " + err.Error())
+	var body_reader io.Reader = response_http.Body
+	if opts != nil && opts.Progress != nil {
+		download := newProgressReader(response_http.Body, response_http.ContentLength, opts.Progress)
+		defer download.finish()
+		body_reader = download
 	}
 
-	bodyStr := string(body)
-	ct := response_http.Header.Get("Content-Type")
-
 	if response_http.StatusCode != 200 {
-		trim := strings.TrimSpace(bodyStr)
-		if strings.HasPrefix(trim, "{") {
-			return fmt.Errorf("%s", FormatGetFileErrorMessage(trim))
-		}
-		if trim == "" {
-			return fmt.Errorf("HTTP %d", response_http.StatusCode)
-		}
-		return fmt.Errorf("%s", trim)
-	}
-
-	if shouldTreatGetFileBodyAsError(bodyStr, ct) {
-		return fmt.Errorf("%s", FormatGetFileErrorMessage(strings.TrimSpace(bodyStr)))
+		return getFileStatusError(response_http.StatusCode, body_reader)
 	}
 
-	_, err = file_writer.Write(body)
-	if err != nil {
-		return errors.New("could not write into given file: " + err.Error())
-	}
-	return nil
+	return streamGetFileBody(body_reader, response_http.Header.Get("Content-Type"), file_writer)
 }
 
 // GenericResultFetch - save a csv containing the results of the file with the given file ID
@@ -362,5 +375,11 @@ func GenericFileDelete(file_id, endpoint string) (*FileValidationResponse, error
 	}
 
 	response_object.FileId = file_id
+	if response_object.Success {
+		error_ = recordJobState(file_id, BulkJobStageDeleted, "", nil)
+		if error_ != nil {
+			return response_object, fmt.Errorf("file %s was deleted, but could not be recorded: %w", file_id, error_)
+		}
+	}
 	return response_object, nil
 }
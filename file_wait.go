@@ -0,0 +1,168 @@
+package zerobouncego
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrFileFailed - the processing of a bulk file failed
+	ErrFileFailed = errors.New("file processing failed")
+	// ErrFileDeleted - a bulk file was deleted before its completion
+	ErrFileDeleted = errors.New("file was deleted")
+	// ErrFileWaitTimeout - a bulk file was not completed within WaitOptions.Timeout
+	ErrFileWaitTimeout = errors.New("timed out waiting for file completion")
+)
+
+const (
+	// WAIT_DEFAULT_INITIAL_INTERVAL - default delay before the second status check
+	WAIT_DEFAULT_INITIAL_INTERVAL = 2 * time.Second
+	// WAIT_DEFAULT_MAX_INTERVAL - default upper bound of the delay between checks
+	WAIT_DEFAULT_MAX_INTERVAL = 30 * time.Second
+	// WAIT_DEFAULT_MULTIPLIER - default growth factor of the delay between checks
+	WAIT_DEFAULT_MULTIPLIER = 1.5
+	// WAIT_DEFAULT_STATUS_ERROR_RETRIES - default number of consecutive failed
+	// status checks tolerated
+	WAIT_DEFAULT_STATUS_ERROR_RETRIES = 3
+)
+
+// WaitOptions - how WaitForFile polls the status of a file
+type WaitOptions struct {
+	// InitialInterval is the delay after the first status check, growing by
+	// Multiplier after each check up to MaxInterval
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	Multiplier      float64
+	// Timeout, if set, bounds the whole wait (ErrFileWaitTimeout)
+	Timeout time.Duration
+	// WaitForPhase2 keeps waiting after phase 1 while the status reports a
+	// phase 2 (see FileStatusResponse.FilePhase2Status) still in progress
+	WaitForPhase2 bool
+	// StatusErrorRetries is the number of consecutive failed status checks
+	// tolerated (defaults to WAIT_DEFAULT_STATUS_ERROR_RETRIES; negative
+	// fails on the first error)
+	StatusErrorRetries int
+	// Progress, if set, receives every status fetched
+	Progress func(status *FileStatusResponse)
+}
+
+func (o WaitOptions) withDefaults() WaitOptions {
+	if o.InitialInterval <= 0 {
+		o.InitialInterval = WAIT_DEFAULT_INITIAL_INTERVAL
+	}
+	if o.MaxInterval < o.InitialInterval {
+		o.MaxInterval = WAIT_DEFAULT_MAX_INTERVAL
+		if o.MaxInterval < o.InitialInterval {
+			o.MaxInterval = o.InitialInterval
+		}
+	}
+	if o.Multiplier < 1 {
+		o.Multiplier = WAIT_DEFAULT_MULTIPLIER
+	}
+	if o.StatusErrorRetries == 0 {
+		o.StatusErrorRetries = WAIT_DEFAULT_STATUS_ERROR_RETRIES
+	}
+	return o
+}
+
+// BulkValidationWaitForFile - wait for the completion of a bulk validation
+// file, see WaitForFile
+func BulkValidationWaitForFile(ctx context.Context, file_id string, options WaitOptions) (*FileStatusResponse, error) {
+	return WaitForFile(ctx, file_id, ENDPOINT_FILE_STATUS, options)
+}
+
+// AiScoringWaitForFile - wait for the completion of an AI scoring file, see
+// WaitForFile
+func AiScoringWaitForFile(ctx context.Context, file_id string, options WaitOptions) (*FileStatusResponse, error) {
+	return WaitForFile(ctx, file_id, ENDPOINT_SCORING_STATUS, options)
+}
+
+// WaitForFile - poll the status of a file, with a growing delay between
+// checks, until it is complete; returns its last status. The wait ends
+// with ErrFileFailed or ErrFileDeleted when the file reaches such a state,
+// ErrFileWaitTimeout after `options.Timeout`, or the context's error.
+func WaitForFile(ctx context.Context, file_id, status_endpoint string, options WaitOptions) (*FileStatusResponse, error) {
+	options = options.withDefaults()
+	wait_ctx := ctx
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		wait_ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	interval := options.InitialInterval
+	failed_checks := 0
+	var status *FileStatusResponse
+	for {
+		current_status, error_ := GenericFileStatusCheck(file_id, status_endpoint)
+		switch {
+		case error_ != nil:
+			failed_checks++
+			if failed_checks > options.StatusErrorRetries {
+				return status, fmt.Errorf("file %s: status check failed: %w", file_id, error_)
+			}
+		case !current_status.Success:
+			return current_status, fmt.Errorf("file %s: status check was not successful", file_id)
+		default:
+			failed_checks = 0
+			status = current_status
+			if options.Progress != nil {
+				options.Progress(status)
+			}
+			done, error_ := fileWaitOutcome(status, options.WaitForPhase2)
+			if error_ != nil {
+				return status, fmt.Errorf("file %s: %w (status %q)", file_id, error_, status.FileStatus)
+			}
+			if done {
+				return status, nil
+			}
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-timer.C:
+		case <-wait_ctx.Done():
+			timer.Stop()
+			if ctx.Err() == nil {
+				return status, fmt.Errorf("file %s: %w after %s", file_id, ErrFileWaitTimeout, options.Timeout)
+			}
+			return status, ctx.Err()
+		}
+		interval = time.Duration(float64(interval) * options.Multiplier)
+		if interval > options.MaxInterval {
+			interval = options.MaxInterval
+		}
+	}
+}
+
+// fileWaitOutcome - whether a status ends the wait, successfully or not
+func fileWaitOutcome(status *FileStatusResponse, wait_for_phase_2 bool) (bool, error) {
+	if error_ := fileStateError(status.FileStatus); error_ != nil {
+		return true, error_
+	}
+	if !strings.EqualFold(strings.TrimSpace(status.FileStatus), "complete") && status.Percentage() < 100 {
+		return false, nil
+	}
+	if !wait_for_phase_2 || status.FilePhase2Status == nil || strings.TrimSpace(*status.FilePhase2Status) == "" {
+		return true, nil
+	}
+	if error_ := fileStateError(*status.FilePhase2Status); error_ != nil {
+		return true, fmt.Errorf("phase 2: %w", error_)
+	}
+	return strings.EqualFold(strings.TrimSpace(*status.FilePhase2Status), "complete"), nil
+}
+
+// fileStateError - terminal error a file status stands for, if any
+func fileStateError(state string) error {
+	state = strings.ToLower(state)
+	switch {
+	case strings.Contains(state, "fail") || strings.Contains(state, "error"):
+		return ErrFileFailed
+	case strings.Contains(state, "delete"):
+		return ErrFileDeleted
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package zerobouncego
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// fastWait - wait options polling every millisecond
+var fastWait = WaitOptions{InitialInterval: time.Millisecond, MaxInterval: time.Millisecond}
+
+func fileStatusBody(file_status, percentage string, phase_2_status string) string {
+	body := `{"success": true, "file_id": "` + testing_file_id + `", "file_status": "` + file_status +
+		`", "complete_percentage": "` + percentage + `"`
+	if phase_2_status != "" {
+		body += `, "file_phase_2_status": "` + phase_2_status + `"`
+	}
+	return body + "}"
+}
+
+// mockStatusSequence - mock a status endpoint answering with the given bodies
+// in turn (an empty body standing for a connection error), the last one
+// being repeated
+func mockStatusSequence(endpoint string, bodies ...string) {
+	var mutex sync.Mutex
+	calls := 0
+	httpmock.RegisterResponder("GET", `=~^(.*)`+endpoint+`(.*)\z`,
+		func(request *http.Request) (*http.Response, error) {
+			mutex.Lock()
+			body := bodies[len(bodies)-1]
+			if calls < len(bodies) {
+				body = bodies[calls]
+			}
+			calls++
+			mutex.Unlock()
+			if body == "" {
+				return nil, errors.New(sample_error_message)
+			}
+			return httpmock.NewStringResponse(200, body), nil
+		},
+	)
+}
+
+func TestWaitForFileCompletes(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	mockStatusSequence(ENDPOINT_FILE_STATUS,
+		fileStatusBody("Queued", "0%", ""),
+		fileStatusBody("Processing", "55% Completed.", ""),
+		fileStatusBody("Complete", "100%", ""),
+	)
+
+	var percentages []float64
+	options := fastWait
+	options.Progress = func(status *FileStatusResponse) { percentages = append(percentages, status.Percentage()) }
+	status, error_ := BulkValidationWaitForFile(context.Background(), testing_file_id, options)
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	assert.Equal(t, "Complete", status.FileStatus)
+	assert.Equal(t, []float64{0, 55, 100}, percentages)
+}
+
+func TestWaitForFileTerminalStates(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	mockStatusSequence(ENDPOINT_SCORING_STATUS, fileStatusBody("Processing", "10%", ""), fileStatusBody("Failed", "10%", ""))
+	status, error_ := AiScoringWaitForFile(context.Background(), testing_file_id, fastWait)
+	assert.True(t, errors.Is(error_, ErrFileFailed))
+	assert.Equal(t, "Failed", status.FileStatus)
+	assert.Equal(t, 2, httpmock.GetCallCountInfo()["GET =~^(.*)"+ENDPOINT_SCORING_STATUS+`(.*)\z`])
+
+	mockStatusSequence(ENDPOINT_SCORING_STATUS, fileStatusBody("Deleted", "0%", ""))
+	_, error_ = AiScoringWaitForFile(context.Background(), testing_file_id, fastWait)
+	assert.True(t, errors.Is(error_, ErrFileDeleted))
+
+	mockStatusSequence(ENDPOINT_SCORING_STATUS, sample_file_validation_status_200_invalid)
+	_, error_ = AiScoringWaitForFile(context.Background(), testing_file_id, fastWait)
+	if assert.NotNil(t, error_) {
+		assert.Contains(t, error_.Error(), "not successful")
+	}
+}
+
+func TestWaitForFileTimeoutAndCancellation(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	mockStatusSequence(ENDPOINT_FILE_STATUS, fileStatusBody("Processing", "10%", ""))
+
+	options := fastWait
+	options.Timeout = 20 * time.Millisecond
+	status, error_ := BulkValidationWaitForFile(context.Background(), testing_file_id, options)
+	assert.True(t, errors.Is(error_, ErrFileWaitTimeout))
+	assert.Equal(t, "Processing", status.FileStatus)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, error_ = BulkValidationWaitForFile(ctx, testing_file_id, fastWait)
+	assert.Equal(t, context.Canceled, error_)
+}
+
+func TestWaitForFilePhase2(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	sequence := []string{
+		fileStatusBody("Complete", "100%", "Processing"),
+		fileStatusBody("Complete", "100%", "Complete"),
+	}
+
+	mockStatusSequence(ENDPOINT_FILE_STATUS, sequence...)
+	status, error_ := BulkValidationWaitForFile(context.Background(), testing_file_id, fastWait)
+	assert.Nil(t, error_)
+	assert.Equal(t, "Processing", *status.FilePhase2Status)
+
+	options := fastWait
+	options.WaitForPhase2 = true
+	mockStatusSequence(ENDPOINT_FILE_STATUS, sequence...)
+	status, error_ = BulkValidationWaitForFile(context.Background(), testing_file_id, options)
+	assert.Nil(t, error_)
+	assert.Equal(t, "Complete", *status.FilePhase2Status)
+
+	mockStatusSequence(ENDPOINT_FILE_STATUS, fileStatusBody("Complete", "100%", "Failed"))
+	_, error_ = BulkValidationWaitForFile(context.Background(), testing_file_id, options)
+	if assert.True(t, errors.Is(error_, ErrFileFailed)) {
+		assert.Contains(t, error_.Error(), "phase 2")
+	}
+
+	// files without phase 2 complete with phase 1
+	mockStatusSequence(ENDPOINT_FILE_STATUS, fileStatusBody("Complete", "100%", ""))
+	_, error_ = BulkValidationWaitForFile(context.Background(), testing_file_id, options)
+	assert.Nil(t, error_)
+}
+
+func TestWaitForFileStatusErrors(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	mockStatusSequence(ENDPOINT_FILE_STATUS, "", "", fileStatusBody("Complete", "100%", ""))
+	_, error_ := BulkValidationWaitForFile(context.Background(), testing_file_id, fastWait)
+	assert.Nil(t, error_)
+
+	mockStatusSequence(ENDPOINT_FILE_STATUS, "", fileStatusBody("Complete", "100%", ""))
+	options := fastWait
+	options.StatusErrorRetries = -1
+	_, error_ = BulkValidationWaitForFile(context.Background(), testing_file_id, options)
+	if assert.NotNil(t, error_) {
+		assert.Contains(t, error_.Error(), "status check failed")
+	}
+}
+
+func TestWaitOptionsDefaults(t *testing.T) {
+	options := WaitOptions{}.withDefaults()
+	assert.Equal(t, WAIT_DEFAULT_INITIAL_INTERVAL, options.InitialInterval)
+	assert.Equal(t, WAIT_DEFAULT_MAX_INTERVAL, options.MaxInterval)
+	assert.Equal(t, WAIT_DEFAULT_MULTIPLIER, options.Multiplier)
+	assert.Equal(t, WAIT_DEFAULT_STATUS_ERROR_RETRIES, options.StatusErrorRetries)
+
+	options = WaitOptions{InitialInterval: time.Minute}.withDefaults()
+	assert.Equal(t, time.Minute, options.MaxInterval)
+}
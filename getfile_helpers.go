@@ -1,10 +1,24 @@
 package zerobouncego
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"strings"
 )
 
+const (
+	// GETFILE_SNIFF_BYTES - number of leading bytes of a getfile body inspected
+	// to tell a JSON error payload from a result file
+	GETFILE_SNIFF_BYTES = 512
+	// GETFILE_MAX_ERROR_BYTES - size above which a getfile body is never
+	// considered an error payload
+	GETFILE_MAX_ERROR_BYTES = 1 << 20
+)
+
 // GetFileJSONIndicatesError reports whether a getfile response body looks like a JSON error payload (including HTTP 200).
 func GetFileJSONIndicatesError(body string) bool {
 	s := strings.TrimSpace(body)
@@ -78,3 +92,89 @@ func shouldTreatGetFileBodyAsError(body, contentType string) bool {
 	}
 	return GetFileJSONIndicatesError(body)
 }
+
+// getFileStatusError - error of a getfile response with an unexpected status
+func getFileStatusError(status_code int, body io.Reader) error {
+	contents, err := io.ReadAll(io.LimitReader(body, GETFILE_MAX_ERROR_BYTES))
+	if err != nil {
+		return errors.New("could not read response body: " + err.Error())
+	}
+	trim := strings.TrimSpace(string(contents))
+	if strings.HasPrefix(trim, "{") {
+		return fmt.Errorf("%s", FormatGetFileErrorMessage(trim))
+	}
+	if trim == "" {
+		return fmt.Errorf("HTTP %d", status_code)
+	}
+	return fmt.Errorf("%s", trim)
+}
+
+// streamGetFileBody - copy a getfile body into the writer as it is received,
+// unless it is a JSON error payload: the body is held in memory only when it
+// is served as JSON or starts with "{", and is small enough to be an error.
+// Nothing is written when an error payload is detected.
+func streamGetFileBody(body io.Reader, contentType string, file_writer io.Writer) error {
+	reader := bufio.NewReaderSize(body, GETFILE_SNIFF_BYTES)
+	head, err := reader.Peek(GETFILE_SNIFF_BYTES)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return &getFileReadError{err}
+	}
+
+	var content io.Reader = reader
+	trimmed_head := bytes.TrimLeft(head, " \t\r\n")
+	if contentTypeIncludesApplicationJSON(contentType) || (len(trimmed_head) > 0 && trimmed_head[0] == '{') {
+		// possibly an error payload: read it whole, if it is small enough
+		buffered, err := io.ReadAll(io.LimitReader(reader, GETFILE_MAX_ERROR_BYTES+1))
+		if err != nil {
+			return &getFileReadError{err}
+		}
+		if len(buffered) <= GETFILE_MAX_ERROR_BYTES && shouldTreatGetFileBodyAsError(string(buffered), contentType) {
+			return fmt.Errorf("%s", FormatGetFileErrorMessage(strings.TrimSpace(string(buffered))))
+		}
+		content = io.MultiReader(bytes.NewReader(buffered), reader)
+	}
+
+	return copyGetFileBody(file_writer, content)
+}
+
+// copyGetFileBody - copy a getfile body into the writer, telling read errors
+// (see getFileReadError) from write errors
+func copyGetFileBody(file_writer io.Writer, body io.Reader) error {
+	writer := &trackedWriter{writer: file_writer}
+	_, err := io.Copy(writer, body)
+	if writer.err != nil {
+		return errors.New("could not write into given file: " + writer.err.Error())
+	}
+	if err != nil {
+		return &getFileReadError{err}
+	}
+	return nil
+}
+
+// getFileReadError - the connection failed while reading a getfile body
+type getFileReadError struct {
+	err error
+}
+
+func (g *getFileReadError) Error() string {
+	return "could not read response body: " + g.err.Error()
+}
+
+func (g *getFileReadError) Unwrap() error {
+	return g.err
+}
+
+// trackedWriter - writer remembering its error, to tell write errors from
+// read errors after an io.Copy
+type trackedWriter struct {
+	writer io.Writer
+	err    error
+}
+
+func (t *trackedWriter) Write(data []byte) (int, error) {
+	count, err := t.writer.Write(data)
+	if err != nil {
+		t.err = err
+	}
+	return count, err
+}
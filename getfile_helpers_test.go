@@ -1,6 +1,12 @@
 package zerobouncego
 
-import "testing"
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestGetFileJSONIndicatesError(t *testing.T) {
 	if !GetFileJSONIndicatesError(`{"success":false,"message":""}`) {
@@ -17,3 +23,90 @@ func TestFormatGetFileErrorMessage(t *testing.T) {
 		t.Fatalf("got %q", msg)
 	}
 }
+
+// signallingWriter - writer closing a channel on its first write
+type signallingWriter struct {
+	strings.Builder
+	first_write chan struct{}
+}
+
+func (s *signallingWriter) Write(data []byte) (int, error) {
+	if s.Len() == 0 {
+		close(s.first_write)
+	}
+	return s.Builder.Write(data)
+}
+
+func TestStreamGetFileBodyWritesAsReceived(t *testing.T) {
+	body_reader, body_writer := io.Pipe()
+	writer := &signallingWriter{first_write: make(chan struct{})}
+	result := make(chan error, 1)
+	go func() { result <- streamGetFileBody(body_reader, CONTENT_TYPE_OCTET_STREAM, writer) }()
+
+	first_chunk := strings.Repeat("\"valid@example.com\",\"valid\"\n", 50)
+	body_writer.Write([]byte(first_chunk))
+	select {
+	case <-writer.first_write:
+	case <-time.After(5 * time.Second):
+		t.Fatal("nothing written before the end of the body")
+	}
+	body_writer.Write([]byte("last@example.com,valid\n"))
+	body_writer.Close()
+	if err := <-result; err != nil {
+		t.Fatal(err)
+	}
+	if writer.String() != first_chunk+"last@example.com,valid\n" {
+		t.Fatal("unexpected contents written")
+	}
+}
+
+func TestStreamGetFileBodyErrorPayloads(t *testing.T) {
+	cases := []struct {
+		body         string
+		content_type string
+		message      string
+	}{
+		{"  {\"success\": false, \"message\": \"File not found\"}", CONTENT_TYPE_OCTET_STREAM, "File not found"},
+		{"{\"error\": \"not ready\"}", "", "not ready"},
+		{"whatever", "application/json; charset=utf-8", "whatever"},
+	}
+	for _, test_case := range cases {
+		output := &strings.Builder{}
+		err := streamGetFileBody(strings.NewReader(test_case.body), test_case.content_type, output)
+		if err == nil || err.Error() != test_case.message {
+			t.Fatalf("expected error %q for %q, got %v", test_case.message, test_case.body, err)
+		}
+		if output.Len() != 0 {
+			t.Fatalf("error payload %q was written", test_case.body)
+		}
+	}
+
+	// bodies too large to be error payloads are written as they are
+	large_body := "{" + strings.Repeat("x", GETFILE_MAX_ERROR_BYTES)
+	output := &strings.Builder{}
+	if err := streamGetFileBody(strings.NewReader(large_body), CONTENT_TYPE_OCTET_STREAM, output); err != nil {
+		t.Fatal(err)
+	}
+	if output.String() != large_body {
+		t.Fatal("large body not written entirely")
+	}
+}
+
+// failingWriter - writer rejecting every write
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestStreamGetFileBodyFailures(t *testing.T) {
+	err := streamGetFileBody(strings.NewReader("a,b\n"), CONTENT_TYPE_OCTET_STREAM, failingWriter{})
+	if err == nil || err.Error() != "could not write into given file: disk full" {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	body := io.MultiReader(strings.NewReader(strings.Repeat("a,b\n", 200)), &failingReader{strings.NewReader("")})
+	output := &strings.Builder{}
+	err = streamGetFileBody(body, CONTENT_TYPE_OCTET_STREAM, output)
+	if err == nil || !strings.HasPrefix(err.Error(), "could not read response body: ") {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
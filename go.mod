@@ -11,5 +11,6 @@ require (
 	github.com/jarcoal/httpmock v1.4.2
 	github.com/joho/godotenv v1.5.1
 	github.com/stretchr/testify v1.11.1
+	golang.org/x/net v0.0.0-20210428140749-89ef3d95e781
 	gopkg.in/guregu/null.v4 v4.0.0
 )
@@ -0,0 +1,133 @@
+package zerobouncego
+
+import (
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// prefix of IDNA encoded domain labels
+const IDNA_ACE_PREFIX = "xn--"
+
+// idnaProfile - UTS #46 lookup processing, non-transitional as in IDNA2008
+// (eg: "ß" is kept rather than mapped to "ss"): labels are mapped (lowercased,
+// NFC-normalized, full-width dots turned into dots) and checked against the
+// IDNA tables and the bidi rule, existing punycode labels being decoded to be
+// checked the same way. Profiles are non-transitional unless told otherwise.
+var idnaProfile = idna.New(idna.MapForLookup(), idna.BidiRule())
+
+func isASCII(value string) bool {
+	for index := 0; index < len(value); index++ {
+		if value[index] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// DomainToASCII - map a domain as IDNA lookups do and convert its
+// internationalized labels to punycode (eg: "Bücher.DE" becomes
+// "xn--bcher-kva.de"); fails on disallowed code points and malformed
+// punycode labels
+func DomainToASCII(domain string) (string, error) {
+	return idnaProfile.ToASCII(domain)
+}
+
+// DomainToUnicode - convert the punycode labels of a domain back to Unicode,
+// for display (eg: "xn--bcher-kva.de" becomes "bücher.de")
+func DomainToUnicode(domain string) (string, error) {
+	return idnaProfile.ToUnicode(domain)
+}
+
+// splitEmail - split an address at its last "@"
+func splitEmail(email string) (string, string, bool) {
+	at_index := strings.LastIndex(email, "@")
+	if at_index < 0 {
+		return email, "", false
+	}
+	return email[:at_index], email[at_index+1:], true
+}
+
+// EmailToASCII - convert the domain of an address to punycode; the local
+// part is kept as is. Addresses without a domain are returned unchanged.
+func EmailToASCII(email string) (string, error) {
+	local_part, domain, ok := splitEmail(email)
+	if !ok || isAddressLiteral(domain) {
+		return email, nil
+	}
+	ascii_domain, error_ := DomainToASCII(domain)
+	if error_ != nil {
+		return email, error_
+	}
+	return local_part + "@" + ascii_domain, nil
+}
+
+// EmailToUnicode - convert the punycode domain of an address to Unicode, for display
+func EmailToUnicode(email string) (string, error) {
+	local_part, domain, ok := splitEmail(email)
+	if !ok || isAddressLiteral(domain) {
+		return email, nil
+	}
+	unicode_domain, error_ := DomainToUnicode(domain)
+	if error_ != nil {
+		return email, error_
+	}
+	return local_part + "@" + unicode_domain, nil
+}
+
+// RequiresSMTPUTF8 - whether the local part of an address holds non-ASCII
+// characters; such addresses can only be delivered by mail servers
+// supporting the SMTPUTF8 extension (RFC 6531), punycode does not apply to them
+func RequiresSMTPUTF8(email string) bool {
+	local_part, _, _ := splitEmail(strings.TrimSpace(email))
+	return !isASCII(local_part)
+}
+
+// InternationalAddress - the different forms of a (possibly internationalized) address
+type InternationalAddress struct {
+	// Original is the address as submitted
+	Original string
+	// ASCII has the domain in punycode; this is the form sent to the API
+	ASCII string
+	// Display has the domain in Unicode
+	Display string
+	// SMTPUTF8 reports a non-ASCII local part (see RequiresSMTPUTF8)
+	SMTPUTF8 bool
+}
+
+// IsInternationalized - whether the address has a non-ASCII domain or local part
+func (a InternationalAddress) IsInternationalized() bool {
+	return a.SMTPUTF8 || a.ASCII != a.Display
+}
+
+// ParseInternationalAddress - compute the ASCII and display forms of an address
+func ParseInternationalAddress(email string) (InternationalAddress, error) {
+	address := InternationalAddress{Original: email, SMTPUTF8: RequiresSMTPUTF8(email)}
+	trimmed := strings.TrimSpace(email)
+
+	ascii_email, error_ := EmailToASCII(trimmed)
+	if error_ != nil {
+		return address, error_
+	}
+	display_email, error_ := EmailToUnicode(ascii_email)
+	if error_ != nil {
+		return address, error_
+	}
+	address.ASCII = ascii_email
+	address.Display = display_email
+	return address, nil
+}
+
+// apiAddress - the form of an address sent to the API: internationalized
+// domains are converted to punycode, every other address is left untouched
+func apiAddress(email string) string {
+	_, domain, ok := splitEmail(email)
+	if !ok || isASCII(domain) {
+		return email
+	}
+	ascii_email, error_ := EmailToASCII(strings.TrimSpace(email))
+	if error_ != nil {
+		return email
+	}
+	return ascii_email
+}
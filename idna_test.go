@@ -0,0 +1,140 @@
+package zerobouncego
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDomainToASCII(t *testing.T) {
+	test_cases := map[string]string{
+		"example.com":       "example.com",
+		"Example.COM":       "example.com",
+		"bücher.de":         "xn--bcher-kva.de",
+		"MÜNCHEN.de":        "xn--mnchen-3ya.de",
+		"españa.com":        "xn--espaa-rta.com",
+		"日本語。jp":            "xn--wgv71a119e.jp",
+		"παράδειγμα.δοκιμή": "xn--hxajbheg2az3al.xn--jxalpdlp",
+		"пример.рф":         "xn--e1afmkfd.xn--p1ai",
+	}
+	for domain, expected := range test_cases {
+		converted, error_ := DomainToASCII(domain)
+		assert.Nil(t, error_)
+		assert.Equalf(t, expected, converted, "domain %s", domain)
+	}
+}
+
+func TestDomainToASCIIValidation(t *testing.T) {
+	// decomposed and precomposed forms give the same label
+	decomposed, error_ := DomainToASCII("bu\u0308cher.de")
+	assert.Nil(t, error_)
+	assert.Equal(t, "xn--bcher-kva.de", decomposed)
+
+	// non-transitional processing keeps deviation characters
+	converted, error_ := DomainToASCII("straße.de")
+	assert.Nil(t, error_)
+	assert.Equal(t, "xn--strae-oqa.de", converted)
+
+	for _, domain := range []string{
+		"xn--bcher-kv!.de",  // malformed punycode
+		"xn--a.de",          // decodes to a disallowed code point
+		"exa\u2028mple.com", // disallowed code point
+		"\u05d0\u0031a.com", // bidi rule
+	} {
+		_, error_ = DomainToASCII(domain)
+		assert.NotNilf(t, error_, "domain %s", domain)
+	}
+}
+
+func TestDomainToUnicode(t *testing.T) {
+	for _, domain := range []string{"bücher.de", "日本語.jp", "παράδειγμα.δοκιμή", "пример.рф", "example.com"} {
+		ascii_domain, error_ := DomainToASCII(domain)
+		assert.Nil(t, error_)
+		unicode_domain, error_ := DomainToUnicode(ascii_domain)
+		assert.Nil(t, error_)
+		assert.Equal(t, domain, unicode_domain)
+	}
+
+	unicode_domain, error_ := DomainToUnicode("XN--BCHER-KVA.de")
+	assert.Nil(t, error_)
+	assert.Equal(t, "bücher.de", unicode_domain)
+
+	_, error_ = DomainToUnicode("xn--bcher-kv!.de")
+	assert.NotNil(t, error_)
+}
+
+func TestParseInternationalAddress(t *testing.T) {
+	address, error_ := ParseInternationalAddress(" user@Bücher.de ")
+	assert.Nil(t, error_)
+	assert.Equal(t, "user@xn--bcher-kva.de", address.ASCII)
+	assert.Equal(t, "user@bücher.de", address.Display)
+	assert.False(t, address.SMTPUTF8)
+	assert.True(t, address.IsInternationalized())
+
+	address, error_ = ParseInternationalAddress("用户@example.com")
+	assert.Nil(t, error_)
+	assert.Equal(t, "用户@example.com", address.ASCII)
+	assert.True(t, address.SMTPUTF8)
+	assert.True(t, address.IsInternationalized())
+
+	address, error_ = ParseInternationalAddress("user@example.com")
+	assert.Nil(t, error_)
+	assert.False(t, address.IsInternationalized())
+
+	assert.True(t, RequiresSMTPUTF8("josé@example.com"))
+	assert.False(t, RequiresSMTPUTF8("jose@bücher.de"))
+}
+
+// mockIDNValidateRequest - mock GET/validate for an internationalized domain,
+// answering only when the address is sent in punycode
+func mockIDNValidateRequest(t *testing.T) {
+	httpmock.RegisterResponder("GET", `=~^(.*)`+ENDPOINT_VALIDATE+`(.*)\z`,
+		func(req *http.Request) (*http.Response, error) {
+			email := req.URL.Query().Get("email")
+			assert.Equal(t, "user@xn--bcher-kva.de", email)
+			return httpmock.NewStringResponse(200, `{"address": "`+email+`", "status": "valid", "sub_status": ""}`), nil
+		},
+	)
+}
+
+func TestValidateInternationalDomain(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	mockIDNValidateRequest(t)
+
+	response, error_ := Validate("user@bücher.de", SANDBOX_IP)
+	assert.Nil(t, error_)
+	assert.Equal(t, "user@xn--bcher-kva.de", response.Address)
+	assert.Equal(t, "user@bücher.de", response.SubmittedAddress)
+}
+
+func TestValidateBatchSubmittedAddresses(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("POST", `=~^(.*)`+ENDPOINT_BATCH_VALIDATE+`(.*)\z`,
+		func(req *http.Request) (*http.Response, error) {
+			body, _ := io.ReadAll(req.Body)
+			assert.Contains(t, string(body), "xn--bcher-kva.de")
+			return httpmock.NewStringResponse(200, `{
+				"email_batch": [
+					{"address": "valid@example.com", "status": "valid"},
+					{"address": "user@xn--bcher-kva.de", "status": "valid"}
+				],
+				"errors": [{"error": "Invalid email", "email_address": "USER@XN--BCHER-KVA.DE"}]
+			}`), nil
+		},
+	)
+
+	response, error_ := ValidateBatch([]EmailToValidate{
+		{EmailAddress: "user@bücher.de"}, {EmailAddress: "valid@example.com"}, {EmailAddress: "user@Bücher.de"},
+	})
+	assert.Nil(t, error_)
+	assert.Equal(t, "user@bücher.de", response.EmailBatch[0].SubmittedAddress)
+	assert.Equal(t, "valid@example.com", response.EmailBatch[1].SubmittedAddress)
+	assert.Equal(t, "user@Bücher.de", response.Errors[0].SubmittedAddress)
+}
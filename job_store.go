@@ -0,0 +1,364 @@
+package zerobouncego
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrJobNotFound - no job is recorded under a file ID
+var ErrJobNotFound = errors.New("job not found")
+
+// JobSubmitOptions - options a file was submitted with
+type JobSubmitOptions struct {
+	RemoveDuplicate    bool   `json:"remove_duplicate"`
+	HasHeaderRow       bool   `json:"has_header_row"`
+	EmailAddressColumn int    `json:"email_address_column"`
+	FirstNameColumn    int    `json:"first_name_column,omitempty"`
+	LastNameColumn     int    `json:"last_name_column,omitempty"`
+	GenderColumn       int    `json:"gender_column,omitempty"`
+	IpAddressColumn    int    `json:"ip_address_column,omitempty"`
+	ReturnURL          string `json:"return_url,omitempty"`
+	AllowPhase2        *bool  `json:"allow_phase_2,omitempty"`
+}
+
+// JobTransition - change of state of a recorded job
+type JobTransition struct {
+	State BulkJobStage `json:"state"`
+	At    time.Time    `json:"at"`
+}
+
+// JobRecord - ledger entry of a file submission
+type JobRecord struct {
+	FileId   string `json:"file_id"`
+	Endpoint string `json:"endpoint"`
+	FileName string `json:"file_name"`
+	// FileSHA256 and FileSize describe the file contents as uploaded
+	FileSHA256 string `json:"file_sha256"`
+	FileSize   int64  `json:"file_size"`
+	// EmailsSHA256 is the digest of the email column (see EmailColumnDigest),
+	// recorded when DUPLICATE_GUARD is set
+	EmailsSHA256 string           `json:"emails_sha256,omitempty"`
+	Options      JobSubmitOptions `json:"options"`
+	State        BulkJobStage     `json:"state"`
+	ResultPath   string           `json:"result_path,omitempty"`
+	// Error is the last error met while processing the job, if any
+	Error       string          `json:"error,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	Transitions []JobTransition `json:"transitions"`
+}
+
+// Kind - kind of bulk job, guessed from the submission endpoint
+func (r JobRecord) Kind() BulkJobKind {
+	if isScoringBulkEndpoint(r.Endpoint) {
+		return BulkJobScoring
+	}
+	return BulkJobValidation
+}
+
+// Job - the recorded job, as resumed by ResumeBulkJob
+func (r JobRecord) Job() BulkJob {
+	return BulkJob{
+		Kind: r.Kind(), FileId: r.FileId, Stage: r.State, ResultPath: r.ResultPath,
+		EmailAddressColumn: r.Options.EmailAddressColumn,
+	}
+}
+
+// Finished - whether nothing is left to do with the job, other than deleting
+// its remote file
+func (r JobRecord) Finished() bool {
+	return r.State == BulkJobStageDone || r.State == BulkJobStageFailed || r.State == BulkJobStageDeleted
+}
+
+// setState - move the record to a state, as of the given time
+func (r *JobRecord) setState(state BulkJobStage, now time.Time) {
+	r.UpdatedAt = now
+	if state == r.State {
+		return
+	}
+	r.State = state
+	r.Transitions = append(r.Transitions, JobTransition{State: state, At: now})
+}
+
+// JobStore - ledger of bulk file submissions, filled by GenericFileSubmit,
+// GenericFileDelete and the bulk job runner when set as `JOB_STORE`.
+// Implementations must be safe for concurrent use.
+type JobStore interface {
+	// Save creates or replaces the record of a file ID
+	Save(record JobRecord) error
+	// Get returns the record of a file ID, or ErrJobNotFound
+	Get(file_id string) (*JobRecord, error)
+	// List returns every record
+	List() ([]JobRecord, error)
+	// Delete forgets the record of a file ID
+	Delete(file_id string) error
+}
+
+// JOB_STORE - ledger of bulk file submissions (nil disables recording)
+var JOB_STORE JobStore
+
+// FileJobStore - job store persisted as a JSON file. The whole file is
+// rewritten on every change.
+type FileJobStore struct {
+	path    string
+	mutex   sync.Mutex
+	records map[string]JobRecord
+}
+
+// NewFileJobStore - open (or create on first write) a file-backed job store
+func NewFileJobStore(path_to_file string) (*FileJobStore, error) {
+	store := &FileJobStore{path: path_to_file, records: make(map[string]JobRecord)}
+	contents, error_ := os.ReadFile(path_to_file)
+	if errors.Is(error_, os.ErrNotExist) {
+		return store, nil
+	}
+	if error_ != nil {
+		return nil, error_
+	}
+	if len(strings.TrimSpace(string(contents))) > 0 {
+		error_ = json.Unmarshal(contents, &store.records)
+		if error_ != nil {
+			return nil, errors.New("could not decode job store file: " + error_.Error())
+		}
+	}
+	return store, nil
+}
+
+// Save - see JobStore
+func (s *FileJobStore) Save(record JobRecord) error {
+	if record.FileId == "" {
+		return errors.New("job record has no file ID")
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	previous, existed := s.records[record.FileId]
+	s.records[record.FileId] = record
+	error_ := s.save()
+	if error_ != nil {
+		if existed {
+			s.records[record.FileId] = previous
+		} else {
+			delete(s.records, record.FileId)
+		}
+	}
+	return error_
+}
+
+// Get - see JobStore
+func (s *FileJobStore) Get(file_id string) (*JobRecord, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	record, ok := s.records[file_id]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return &record, nil
+}
+
+// List - see JobStore; records are sorted by creation time
+func (s *FileJobStore) List() ([]JobRecord, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	records := make([]JobRecord, 0, len(s.records))
+	for _, record := range s.records {
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].CreatedAt.Equal(records[j].CreatedAt) {
+			return records[i].FileId < records[j].FileId
+		}
+		return records[i].CreatedAt.Before(records[j].CreatedAt)
+	})
+	return records, nil
+}
+
+// Delete - see JobStore
+func (s *FileJobStore) Delete(file_id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	record, ok := s.records[file_id]
+	if !ok {
+		return nil
+	}
+	delete(s.records, file_id)
+	error_ := s.save()
+	if error_ != nil {
+		s.records[file_id] = record
+	}
+	return error_
+}
+
+func (s *FileJobStore) save() error {
+	contents, error_ := json.MarshalIndent(s.records, "", "  ")
+	if error_ != nil {
+		return error_
+	}
+	return writeFileAtomic(s.path, contents)
+}
+
+// recordJobSubmission - add a submitted file to `JOB_STORE`
+func recordJobSubmission(file_id, endpoint string, csv_file CsvFile, remove_duplicate bool, upload *multipartUpload, emails_sha256 string) error {
+	store := JOB_STORE
+	if store == nil {
+		return nil
+	}
+	file_sha256, file_size, error_ := upload.digest()
+	if error_ != nil {
+		return error_
+	}
+	now := time.Now()
+	record := JobRecord{
+		FileId: file_id, Endpoint: endpoint, FileName: csv_file.FileName,
+		FileSHA256: file_sha256, FileSize: file_size, EmailsSHA256: emails_sha256,
+		Options: JobSubmitOptions{
+			RemoveDuplicate:    remove_duplicate,
+			HasHeaderRow:       csv_file.HasHeaderRow,
+			EmailAddressColumn: csv_file.EmailAddressColumn,
+			FirstNameColumn:    csv_file.FirstNameColumn,
+			LastNameColumn:     csv_file.LastNameColumn,
+			GenderColumn:       csv_file.GenderColumn,
+			IpAddressColumn:    csv_file.IpAddressColumn,
+			ReturnURL:          csv_file.ReturnURL,
+			AllowPhase2:        csv_file.AllowPhase2,
+		},
+		CreatedAt: now,
+	}
+	record.setState(BulkJobStageSubmitted, now)
+	return store.Save(record)
+}
+
+// recordJobState - update the state (and result path, if set) of a job in
+// `JOB_STORE`; jobs that were not recorded are ignored
+func recordJobState(file_id string, state BulkJobStage, result_path string, cause error) error {
+	store := JOB_STORE
+	if store == nil {
+		return nil
+	}
+	record, error_ := store.Get(file_id)
+	if errors.Is(error_, ErrJobNotFound) {
+		return nil
+	}
+	if error_ != nil {
+		return error_
+	}
+	if state != "" {
+		record.setState(state, time.Now())
+	} else {
+		record.UpdatedAt = time.Now()
+	}
+	if result_path != "" {
+		record.ResultPath = result_path
+	}
+	record.Error = ""
+	if cause != nil {
+		record.Error = cause.Error()
+	}
+	return store.Save(*record)
+}
+
+// ListJobs - jobs recorded in `JOB_STORE` being in one of the given states
+// (any state when none is given)
+func ListJobs(states ...BulkJobStage) ([]JobRecord, error) {
+	store := JOB_STORE
+	if store == nil {
+		return nil, errors.New("no job store is set")
+	}
+	records, error_ := store.List()
+	if error_ != nil || len(states) == 0 {
+		return records, error_
+	}
+	var filtered []JobRecord
+	for _, record := range records {
+		for _, state := range states {
+			if record.State == state {
+				filtered = append(filtered, record)
+				break
+			}
+		}
+	}
+	return filtered, nil
+}
+
+// JobOutcome - what became of a recorded job handled by ResumeJobs or
+// CleanupJobs
+type JobOutcome struct {
+	FileId string
+	// Result is set by ResumeJobs
+	Result *BulkJobResult
+	Error  error
+}
+
+// ResumeJobs - carry every unfinished job of `JOB_STORE` on with
+// ResumeBulkJob, one after the other; `options.ResultPath` is used for jobs
+// without a recorded result path
+func ResumeJobs(ctx context.Context, options BulkJobOptions) ([]JobOutcome, error) {
+	records, error_ := ListJobs()
+	if error_ != nil {
+		return nil, error_
+	}
+	var outcomes []JobOutcome
+	for _, record := range records {
+		if record.Finished() {
+			continue
+		}
+		if error_ := ctx.Err(); error_ != nil {
+			return outcomes, error_
+		}
+		job := record.Job()
+		if job.ResultPath == "" && options.ResultPath != "" {
+			job.ResultPath = options.ResultPath + "." + record.FileId
+		}
+		result, error_ := ResumeBulkJob(ctx, job, options)
+		outcomes = append(outcomes, JobOutcome{FileId: record.FileId, Result: result, Error: error_})
+	}
+	return outcomes, nil
+}
+
+// CleanupJobs - delete from the server the files of finished jobs (see
+// JobRecord.Finished) last updated more than `older_than` ago and not
+// deleted yet; unfinished jobs are left alone, however old, their results
+// being still to fetch (see ResumeJobs). With `forget`, the records of
+// deleted jobs are then removed from the store.
+func CleanupJobs(older_than time.Duration, forget bool) ([]JobOutcome, error) {
+	records, error_ := ListJobs()
+	if error_ != nil {
+		return nil, error_
+	}
+	cutoff := time.Now().Add(-older_than)
+	var outcomes []JobOutcome
+	for _, record := range records {
+		if !record.Finished() || record.UpdatedAt.After(cutoff) {
+			continue
+		}
+		if record.State != BulkJobStageDeleted {
+			endpoints, _ := record.Kind().endpoints()
+			response, error_ := GenericFileDelete(record.FileId, endpoints.delete)
+			if error_ == nil && !response.Success {
+				error_ = fmt.Errorf("%v", response.Message)
+			}
+			if error_ != nil {
+				if record_error := recordJobState(record.FileId, "", "", error_); record_error != nil {
+					error_ = fmt.Errorf("%w (the error could not be recorded: %v)", error_, record_error)
+				}
+			}
+			outcomes = append(outcomes, JobOutcome{FileId: record.FileId, Error: error_})
+			if error_ != nil {
+				continue
+			}
+		}
+		if forget {
+			error_ = JOB_STORE.Delete(record.FileId)
+			if error_ != nil {
+				return outcomes, error_
+			}
+		}
+	}
+	return outcomes, nil
+}
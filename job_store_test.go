@@ -0,0 +1,206 @@
+package zerobouncego
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// useFileJobStore - set `JOB_STORE` to a new file store for the test
+func useFileJobStore(t *testing.T) *FileJobStore {
+	store, error_ := NewFileJobStore(filepath.Join(t.TempDir(), "jobs.json"))
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	JOB_STORE = store
+	t.Cleanup(func() { JOB_STORE = nil })
+	return store
+}
+
+func recordStates(record *JobRecord) []BulkJobStage {
+	var states []BulkJobStage
+	for _, transition := range record.Transitions {
+		states = append(states, transition.State)
+	}
+	return states
+}
+
+func TestFileJobStore(t *testing.T) {
+	path_to_file := filepath.Join(t.TempDir(), "jobs.json")
+	store, error_ := NewFileJobStore(path_to_file)
+	assert.Nil(t, error_)
+
+	_, error_ = store.Get("missing")
+	assert.True(t, errors.Is(error_, ErrJobNotFound))
+	assert.NotNil(t, store.Save(JobRecord{}))
+
+	now := time.Now().UTC().Truncate(time.Second)
+	assert.Nil(t, store.Save(JobRecord{FileId: "second", State: BulkJobStageSubmitted, CreatedAt: now.Add(time.Minute)}))
+	assert.Nil(t, store.Save(JobRecord{FileId: "first", State: BulkJobStageDone, CreatedAt: now}))
+
+	reopened, error_ := NewFileJobStore(path_to_file)
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	records, error_ := reopened.List()
+	assert.Nil(t, error_)
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, "first", records[0].FileId)
+		assert.Equal(t, BulkJobStageDone, records[0].State)
+		assert.True(t, now.Equal(records[0].CreatedAt))
+		assert.Equal(t, "second", records[1].FileId)
+	}
+
+	assert.Nil(t, reopened.Delete("first"))
+	assert.Nil(t, reopened.Delete("first"))
+	_, error_ = reopened.Get("first")
+	assert.True(t, errors.Is(error_, ErrJobNotFound))
+
+	assert.Nil(t, os.WriteFile(path_to_file, []byte("{not json"), 0644))
+	_, error_ = NewFileJobStore(path_to_file)
+	assert.NotNil(t, error_)
+}
+
+func TestJobStoreRecordsSubmissionAndDeletion(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	store := useFileJobStore(t)
+	mockSendFile(ENDPOINT_SCORING_SEND)
+	mockOkResponse("GET", ENDPOINT_SCORING_DELETE, sample_scoring_delete_200_success)
+
+	contents := "email\nvalid@example.com\n"
+	csv_file := CsvFile{File: strings.NewReader(contents), FileName: "emails.csv", HasHeaderRow: true, EmailAddressColumn: 1, IpAddressColumn: 2}
+	_, error_ := AiScoringFileSubmit(csv_file, true)
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	record, error_ := store.Get(testing_file_id)
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	checksum := sha256.Sum256([]byte(contents))
+	assert.Equal(t, hex.EncodeToString(checksum[:]), record.FileSHA256)
+	assert.Equal(t, int64(len(contents)), record.FileSize)
+	assert.Equal(t, ENDPOINT_SCORING_SEND, record.Endpoint)
+	assert.Equal(t, BulkJobScoring, record.Kind())
+	assert.Equal(t, "emails.csv", record.FileName)
+	assert.Equal(t, JobSubmitOptions{RemoveDuplicate: true, HasHeaderRow: true, EmailAddressColumn: 1, IpAddressColumn: 2}, record.Options)
+	assert.Equal(t, BulkJobStageSubmitted, record.State)
+	assert.False(t, record.CreatedAt.IsZero())
+
+	_, error_ = AiScoringFileDelete(testing_file_id)
+	assert.Nil(t, error_)
+	record, _ = store.Get(testing_file_id)
+	assert.Equal(t, []BulkJobStage{BulkJobStageSubmitted, BulkJobStageDeleted}, recordStates(record))
+}
+
+func TestJobStoreRecordsBulkJobStages(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	store := useFileJobStore(t)
+	mockSendFile(ENDPOINT_FILE_SEND)
+	mockStatusSequence(ENDPOINT_FILE_STATUS, sample_file_validation_status_200_ok)
+	mockResultFile(ENDPOINT_FILE_RESULT, sample_list_validation_result)
+
+	result_path := filepath.Join(t.TempDir(), "results.csv")
+	csv_file := CsvFile{File: strings.NewReader("valid@example.com\n"), FileName: "emails.csv", EmailAddressColumn: 1}
+	_, error_ := RunBulkJob(context.Background(), csv_file, BulkJobOptions{Wait: fastWait, ResultPath: result_path})
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	record, _ := store.Get(testing_file_id)
+	assert.Equal(t, []BulkJobStage{BulkJobStageSubmitted, BulkJobStageCompleted, BulkJobStageDownloaded, BulkJobStageDone}, recordStates(record))
+	assert.Equal(t, result_path, record.ResultPath)
+	assert.True(t, record.Finished())
+	assert.Equal(t, "", record.Error)
+
+	mockStatusSequence(ENDPOINT_FILE_STATUS, fileStatusBody("Failed", "0%", ""))
+	assert.Nil(t, store.Save(JobRecord{FileId: "failing", Endpoint: ENDPOINT_FILE_SEND, State: BulkJobStageSubmitted}))
+	_, error_ = ResumeBulkJob(context.Background(), BulkJob{FileId: "failing"}, BulkJobOptions{Wait: fastWait})
+	assert.True(t, errors.Is(error_, ErrFileFailed))
+	record, _ = store.Get("failing")
+	assert.Equal(t, BulkJobStageFailed, record.State)
+	assert.Contains(t, record.Error, "failed")
+}
+
+func TestResumeJobs(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	store := useFileJobStore(t)
+	mockStatusSequence(ENDPOINT_FILE_STATUS, sample_file_validation_status_200_ok)
+	mockResultFile(ENDPOINT_FILE_RESULT, sample_list_validation_result)
+
+	_, error_ := ListJobs()
+	assert.Nil(t, error_)
+	assert.Nil(t, store.Save(JobRecord{FileId: "pending", Endpoint: ENDPOINT_FILE_SEND, State: BulkJobStageSubmitted}))
+	assert.Nil(t, store.Save(JobRecord{FileId: "done", Endpoint: ENDPOINT_FILE_SEND, State: BulkJobStageDone}))
+	assert.Nil(t, store.Save(JobRecord{FileId: "deleted", Endpoint: ENDPOINT_FILE_SEND, State: BulkJobStageDeleted}))
+
+	unfinished, error_ := ListJobs(BulkJobStageSubmitted, BulkJobStageCompleted)
+	assert.Nil(t, error_)
+	assert.Len(t, unfinished, 1)
+
+	outcomes, error_ := ResumeJobs(context.Background(), BulkJobOptions{Wait: fastWait})
+	assert.Nil(t, error_)
+	if assert.Len(t, outcomes, 1) {
+		assert.Equal(t, "pending", outcomes[0].FileId)
+		assert.Nil(t, outcomes[0].Error)
+		assert.Len(t, outcomes[0].Result.ValidationRows, 2)
+	}
+	record, _ := store.Get("pending")
+	assert.Equal(t, BulkJobStageDone, record.State)
+
+	JOB_STORE = nil
+	_, error_ = ResumeJobs(context.Background(), BulkJobOptions{})
+	assert.NotNil(t, error_)
+}
+
+func TestCleanupJobs(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	store := useFileJobStore(t)
+	mockOkResponse("GET", ENDPOINT_FILE_DELETE, sample_validation_delete_200_success)
+
+	now := time.Now()
+	assert.Nil(t, store.Save(JobRecord{FileId: "orphan", Endpoint: ENDPOINT_FILE_SEND, State: BulkJobStageDone, UpdatedAt: now.Add(-48 * time.Hour)}))
+	assert.Nil(t, store.Save(JobRecord{FileId: "gone", Endpoint: ENDPOINT_FILE_SEND, State: BulkJobStageDeleted, UpdatedAt: now.Add(-48 * time.Hour)}))
+	assert.Nil(t, store.Save(JobRecord{FileId: "recent", Endpoint: ENDPOINT_FILE_SEND, State: BulkJobStageDone, UpdatedAt: now}))
+	// still processing: its results are not lost, however old
+	assert.Nil(t, store.Save(JobRecord{FileId: "slow", Endpoint: ENDPOINT_FILE_SEND, State: BulkJobStageSubmitted, UpdatedAt: now.Add(-48 * time.Hour)}))
+
+	outcomes, error_ := CleanupJobs(24*time.Hour, false)
+	assert.Nil(t, error_)
+	assert.Equal(t, []JobOutcome{{FileId: "orphan"}}, outcomes)
+	record, _ := store.Get("orphan")
+	assert.Equal(t, BulkJobStageDeleted, record.State)
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()["GET =~^(.*)"+ENDPOINT_FILE_DELETE+`(.*)\z`])
+
+	// a failed deletion is reported and the job kept
+	mockOkResponse("GET", ENDPOINT_FILE_DELETE, sample_validation_delete_200_not_success)
+	outcomes, error_ = CleanupJobs(-time.Hour, true)
+	assert.Nil(t, error_)
+	if assert.Len(t, outcomes, 1) {
+		assert.Equal(t, "recent", outcomes[0].FileId)
+		assert.NotNil(t, outcomes[0].Error)
+	}
+	records, _ := store.List()
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, "recent", records[0].FileId)
+		assert.NotEmpty(t, records[0].Error)
+		assert.Equal(t, "slow", records[1].FileId)
+		assert.Equal(t, BulkJobStageSubmitted, records[1].State)
+	}
+}
@@ -0,0 +1,214 @@
+package zerobouncego
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ValidationMode - API used to validate a list
+type ValidationMode string
+
+const (
+	// ValidationModeAuto lets ValidateList choose, see ChooseValidationMode
+	ValidationModeAuto ValidationMode = ""
+	// ValidationModeSingle validates each email with ValidateWithTimeout
+	ValidationModeSingle ValidationMode = "single"
+	// ValidationModeBatch validates emails with ValidateBatch
+	ValidationModeBatch ValidationMode = "batch"
+	// ValidationModeBulk uploads the list as a bulk validation file
+	ValidationModeBulk ValidationMode = "bulk"
+)
+
+// LIST_SINGLE_MAX_EMAILS - lists up to this size are validated one by one
+var LIST_SINGLE_MAX_EMAILS = 10
+
+// LIST_BATCH_MAX_EMAILS - lists up to this size are validated through the
+// batch endpoint, larger ones are uploaded as a bulk file
+var LIST_BATCH_MAX_EMAILS = 5000
+
+// LIST_BULK_POLL_INTERVAL - default delay between two bulk file status checks
+var LIST_BULK_POLL_INTERVAL = 10 * time.Second
+
+// ListOptions - how ValidateList validates a list
+type ListOptions struct {
+	// Mode forces an API; ValidationModeAuto picks one by list size
+	Mode ValidationMode
+	// LatencyTolerant prefers the bulk file API for every list not small
+	// enough for single validations, as results are not needed right away
+	LatencyTolerant bool
+	// Workers is the number of single validations or batch requests in flight
+	Workers int
+	// Batch tunes batch requests (ChunkSize and RequestsPerMinute)
+	Batch BatchOptions
+	// Timeout is passed to ValidateWithTimeout (single mode only)
+	Timeout string
+	// PollInterval is the delay between bulk file status checks, defaults to
+	// LIST_BULK_POLL_INTERVAL
+	PollInterval time.Duration
+	// KeepBulkFile leaves the bulk file on the server once its results are
+	// fetched (it is deleted by default)
+	KeepBulkFile bool
+}
+
+// AddressResult - outcome of one email of a list, whichever API was used
+type AddressResult struct {
+	Input    EmailToValidate
+	Response *ValidateResponse
+	// Error is set when the email could not be validated
+	Error error
+}
+
+// ListValidation - outcome of ValidateList, with one result per input email,
+// in input order
+type ListValidation struct {
+	Mode ValidationMode
+	// FileId is the bulk file ID (bulk mode only)
+	FileId  string
+	Results []AddressResult
+}
+
+// Responses - the responses of the validated emails, in input order
+func (l ListValidation) Responses() []ValidateResponse {
+	var responses []ValidateResponse
+	for _, result := range l.Results {
+		if result.Response != nil {
+			responses = append(responses, *result.Response)
+		}
+	}
+	return responses
+}
+
+// ChooseValidationMode - API ValidateList uses for a list of `count` emails
+func ChooseValidationMode(count int, options ListOptions) ValidationMode {
+	if options.Mode != ValidationModeAuto {
+		return options.Mode
+	}
+	switch {
+	case count <= LIST_SINGLE_MAX_EMAILS:
+		return ValidationModeSingle
+	case options.LatencyTolerant || count > LIST_BATCH_MAX_EMAILS:
+		return ValidationModeBulk
+	default:
+		return ValidationModeBatch
+	}
+}
+
+// ValidateList - validate a list through the single, batch or bulk file API
+// (see ChooseValidationMode), running the whole lifecycle of the chosen API,
+// and return one result per email in input order. Emails that could not be
+// validated have their `Error` set; the returned error is about the list as
+// a whole (eg: cancellation, failed upload).
+func ValidateList(ctx context.Context, emails_list []EmailToValidate, options ListOptions) (ListValidation, error) {
+	mode := ChooseValidationMode(len(emails_list), options)
+	validation := ListValidation{Mode: mode, Results: make([]AddressResult, len(emails_list))}
+	for index, email := range emails_list {
+		validation.Results[index].Input = email
+	}
+	if len(emails_list) == 0 {
+		return validation, nil
+	}
+
+	switch mode {
+	case ValidationModeSingle, ValidationModeBatch:
+		stream_options := StreamOptions{
+			UseBatch:          mode == ValidationModeBatch,
+			BatchSize:         options.Batch.ChunkSize,
+			Workers:           options.Workers,
+			RequestsPerMinute: options.Batch.RequestsPerMinute,
+			Timeout:           options.Timeout,
+		}
+		sink := func(result StreamResult) error {
+			validation.Results[result.Position].Response = result.Response
+			validation.Results[result.Position].Error = result.Error
+			return nil
+		}
+		_, error_ := ValidateStream(ctx, sliceSource(emails_list), sink, stream_options)
+		return validation, error_
+	case ValidationModeBulk:
+		return validation, validateListBulk(ctx, &validation, options)
+	}
+	return validation, fmt.Errorf("unknown validation mode %q", mode)
+}
+
+// sliceSource - stream the emails of a list
+func sliceSource(emails_list []EmailToValidate) StreamSource {
+	return func(ctx context.Context, emails chan<- EmailToValidate) error {
+		for _, email := range emails_list {
+			if error_ := sendStreamEmail(ctx, emails, email); error_ != nil {
+				return error_
+			}
+		}
+		return nil
+	}
+}
+
+// validateListBulk - upload the list as a bulk file, wait for its completion,
+// fetch and parse the results, then delete the file
+func validateListBulk(ctx context.Context, validation *ListValidation, options ListOptions) error {
+	entries := make([]BatchEntry, len(validation.Results))
+	has_ip_address := false
+	for index, result := range validation.Results {
+		entries[index] = BatchEntry{Key: strconv.Itoa(index), EmailToValidate: result.Input}
+		has_ip_address = has_ip_address || result.Input.IPAddress != ""
+	}
+
+	contents := &bytes.Buffer{}
+	writer := csv.NewWriter(contents)
+	writer.Write([]string{"email", "ip_address"})
+	for _, entry := range entries {
+		writer.Write([]string{entry.EmailAddress, entry.IPAddress})
+	}
+	writer.Flush()
+	csv_file := CsvFile{File: contents, FileName: "list.csv", HasHeaderRow: true, EmailAddressColumn: 1}
+	if has_ip_address {
+		csv_file.IpAddressColumn = 2
+	}
+
+	submit_response, error_ := BulkValidationSubmit(csv_file, false)
+	if error_ != nil {
+		return error_
+	}
+	if !submit_response.Success || submit_response.FileId == "" {
+		return fmt.Errorf("bulk file submission failed: %v", submit_response.Message)
+	}
+	validation.FileId = submit_response.FileId
+
+	poll_interval := options.PollInterval
+	if poll_interval <= 0 {
+		poll_interval = LIST_BULK_POLL_INTERVAL
+	}
+	_, error_ = BulkValidationWaitForFile(ctx, validation.FileId, WaitOptions{InitialInterval: poll_interval, MaxInterval: poll_interval})
+	if error_ != nil {
+		return error_
+	}
+	result_contents := &bytes.Buffer{}
+	error_ = BulkValidationResult(validation.FileId, result_contents)
+	if error_ != nil {
+		return error_
+	}
+	rows, error_ := ParseValidationResults(result_contents, nil)
+	if error_ != nil {
+		return error_
+	}
+	responses := make([]ValidateResponse, 0, len(rows))
+	for _, row := range rows {
+		responses = append(responses, row.Final())
+	}
+	if !options.KeepBulkFile {
+		// the results are already fetched, failing to delete is not fatal
+		BulkValidationFileDelete(validation.FileId)
+	}
+
+	correlated := CorrelateBatch(entries, ValidateBatchResponse{EmailBatch: responses})
+	for index, batch_result := range correlated.Results {
+		validation.Results[index].Response = batch_result.Response
+		if batch_result.Missing {
+			validation.Results[index].Error = ErrMissingBatchResult
+		}
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package zerobouncego
+
+import (
+	"context"
+	"encoding/csv"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const sample_list_validation_result = "\ufeff\"Email Address\",\"ZB Status\",\"ZB Sub Status\",\"ZB Free Email\",\"ZB Did You Mean\",\"ZB Domain\"\n" +
+	"\"valid@example.com\",\"valid\",\"\",\"False\",\"\",\"example.com\"\n" +
+	"\"USER@GMAL.COM\",\"invalid\",\"possible_typo\",\"True\",\"user@gmail.com\",\"gmal.com\"\n"
+
+func TestChooseValidationMode(t *testing.T) {
+	assert.Equal(t, ValidationModeSingle, ChooseValidationMode(1, ListOptions{}))
+	assert.Equal(t, ValidationModeSingle, ChooseValidationMode(LIST_SINGLE_MAX_EMAILS, ListOptions{}))
+	assert.Equal(t, ValidationModeBatch, ChooseValidationMode(LIST_SINGLE_MAX_EMAILS+1, ListOptions{}))
+	assert.Equal(t, ValidationModeBatch, ChooseValidationMode(LIST_BATCH_MAX_EMAILS, ListOptions{}))
+	assert.Equal(t, ValidationModeBulk, ChooseValidationMode(LIST_BATCH_MAX_EMAILS+1, ListOptions{}))
+	assert.Equal(t, ValidationModeBulk, ChooseValidationMode(LIST_SINGLE_MAX_EMAILS+1, ListOptions{LatencyTolerant: true}))
+	assert.Equal(t, ValidationModeSingle, ChooseValidationMode(2, ListOptions{LatencyTolerant: true}))
+	assert.Equal(t, ValidationModeBatch, ChooseValidationMode(2, ListOptions{Mode: ValidationModeBatch}))
+}
+
+func TestValidateListSingle(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	mockValidateRequest()
+
+	validation, error_ := ValidateList(context.Background(), []EmailToValidate{
+		{EmailAddress: "valid@example.com"},
+		{EmailAddress: "not-mocked@example.com"},
+		{EmailAddress: "invalid@example.com", IPAddress: SANDBOX_IP},
+	}, ListOptions{})
+	if !assert.Nil(t, error_) || !assert.Len(t, validation.Results, 3) {
+		t.FailNow()
+	}
+	assert.Equal(t, ValidationModeSingle, validation.Mode)
+	assert.Equal(t, S_VALID, validation.Results[0].Response.Status)
+	assert.NotNil(t, validation.Results[1].Error)
+	assert.Nil(t, validation.Results[1].Response)
+	assert.Equal(t, S_INVALID, validation.Results[2].Response.Status)
+	assert.Equal(t, SANDBOX_IP, validation.Results[2].Input.IPAddress)
+	assert.Len(t, validation.Responses(), 2)
+}
+
+func TestValidateListBatch(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	mockBatchValidateRequest()
+
+	emails := EmailsToValidate()
+	validation, error_ := ValidateList(context.Background(), emails, ListOptions{
+		Batch: BatchOptions{ChunkSize: 10, RequestsPerMinute: -1},
+	})
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	assert.Equal(t, ValidationModeBatch, validation.Mode)
+	assert.Equal(t, 4, httpmock.GetCallCountInfo()["POST =~^(.*)"+ENDPOINT_BATCH_VALIDATE+`(.*)\z`])
+	for index, result := range validation.Results {
+		if assert.NotNil(t, result.Response) {
+			assert.Equal(t, emailsToValidate[index].Email, result.Response.Address)
+			assert.Equal(t, emailsToValidate[index].Status, result.Response.Status)
+		}
+	}
+}
+
+func TestValidateListBulk(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var uploaded [][]string
+	httpmock.RegisterResponder("POST", `=~^(.*)`+ENDPOINT_FILE_SEND+`(.*)\z`,
+		func(request *http.Request) (*http.Response, error) {
+			file, _, error_ := request.FormFile("file")
+			if error_ != nil {
+				return nil, error_
+			}
+			uploaded, _ = csv.NewReader(file).ReadAll()
+			assert.Equal(t, "true", request.FormValue("has_header_row"))
+			assert.Equal(t, "", request.FormValue("ip_address_column"))
+			return httpmock.NewStringResponse(201, send_file_response_200), nil
+		},
+	)
+	var status_checks int32
+	httpmock.RegisterResponder("GET", `=~^(.*)`+ENDPOINT_FILE_STATUS+`(.*)\z`,
+		func(request *http.Request) (*http.Response, error) {
+			if atomic.AddInt32(&status_checks, 1) == 1 {
+				return httpmock.NewStringResponse(200, `{"success": true, "file_status": "Processing", "complete_percentage": "40%"}`), nil
+			}
+			return httpmock.NewStringResponse(200, sample_file_validation_status_200_ok), nil
+		},
+	)
+	httpmock.RegisterResponder("GET", `=~^(.*)`+ENDPOINT_FILE_RESULT+`(.*)\z`,
+		func(request *http.Request) (*http.Response, error) {
+			response := httpmock.NewStringResponse(200, sample_list_validation_result)
+			response.Header.Set("Content-Type", CONTENT_TYPE_OCTET_STREAM)
+			return response, nil
+		},
+	)
+	mockOkResponse("GET", ENDPOINT_FILE_DELETE, sample_validation_delete_200_success)
+
+	validation, error_ := ValidateList(context.Background(), []EmailToValidate{
+		{EmailAddress: "user@gmal.com"},
+		{EmailAddress: "valid@example.com"},
+		{EmailAddress: "lost@example.com"},
+	}, ListOptions{Mode: ValidationModeBulk, PollInterval: time.Millisecond})
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	assert.Equal(t, ValidationModeBulk, validation.Mode)
+	assert.Equal(t, testing_file_id, validation.FileId)
+	assert.Equal(t, [][]string{{"email", "ip_address"}, {"user@gmal.com", ""}, {"valid@example.com", ""}, {"lost@example.com", ""}}, uploaded)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&status_checks))
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()["GET =~^(.*)"+ENDPOINT_FILE_DELETE+`(.*)\z`])
+
+	typo := validation.Results[0].Response
+	if assert.NotNil(t, typo) {
+		assert.Equal(t, S_INVALID, typo.Status)
+		assert.Equal(t, SS_POSSIBLE_TYPO, typo.SubStatus)
+		assert.True(t, typo.FreeEmail)
+		assert.Equal(t, "user@gmail.com", typo.DidYouMean.String)
+		assert.Equal(t, "gmal.com", typo.Domain)
+	}
+	if assert.NotNil(t, validation.Results[1].Response) {
+		assert.Equal(t, S_VALID, validation.Results[1].Response.Status)
+		assert.False(t, validation.Results[1].Response.DidYouMean.Valid)
+	}
+	assert.Equal(t, ErrMissingBatchResult, validation.Results[2].Error)
+}
+
+func TestValidateListBulkCancelled(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("POST", `=~^(.*)`+ENDPOINT_FILE_SEND+`(.*)\z`,
+		httpmock.NewStringResponder(201, send_file_response_200))
+	mockOkResponse("GET", ENDPOINT_FILE_STATUS, `{"success": true, "file_status": "Processing", "complete_percentage": "0%"}`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	validation, error_ := ValidateList(ctx, EmailsToValidate(), ListOptions{LatencyTolerant: true, PollInterval: time.Millisecond})
+	assert.Equal(t, context.DeadlineExceeded, error_)
+	assert.Equal(t, testing_file_id, validation.FileId)
+}
@@ -0,0 +1,169 @@
+package zerobouncego
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// MERGE_DEFAULT_COLUMNS - result columns appended by MergeResults when none
+// are given
+var MERGE_DEFAULT_COLUMNS = []string{"ZB Status", "ZB Sub Status", "ZB Did You Mean"}
+
+// MergeOptions - how MergeResults annotates a file
+type MergeOptions struct {
+	// Columns are the headers of the result columns to append, in order
+	// (MERGE_DEFAULT_COLUMNS by default); they are matched whatever their
+	// case and separators, as in "zb_status"
+	Columns []string
+	// ResultEmailAddressColumn is the 1-based column of the addresses in the
+	// result file (defaults to the email column of the original file)
+	ResultEmailAddressColumn int
+}
+
+// MergeSummary - row counts of a merge
+type MergeSummary struct {
+	// Rows is the number of rows of the original file, header excluded
+	Rows int
+	// Matched rows got the values of a result row, unmatched rows empty cells
+	Matched   int
+	Unmatched int
+}
+
+// MergeResults - write the rows of the original file to `output`, in the
+// same order and duplicates included, with the given result columns
+// appended. Rows are joined on their address, normalized (see
+// NormalizeEmail) and lowercased; the first result row of an address is used
+// for all its occurrences, such that rows dropped with `remove_duplicate`
+// are annotated too. The original file is read from its current position,
+// and its header row, if any, is extended with the appended columns.
+func MergeResults(original CsvFile, results io.Reader, output io.Writer, options *MergeOptions) (MergeSummary, error) {
+	summary := MergeSummary{}
+	if options == nil {
+		options = &MergeOptions{}
+	}
+	if original.File == nil {
+		return summary, errors.New("csv file has no contents")
+	}
+	if original.EmailAddressColumn < 1 {
+		return summary, errors.New("the email address column is not set")
+	}
+	columns := options.Columns
+	if len(columns) == 0 {
+		columns = MERGE_DEFAULT_COLUMNS
+	}
+	result_email_column := options.ResultEmailAddressColumn
+	if result_email_column < 1 {
+		result_email_column = original.EmailAddressColumn
+	}
+	annotations, error_ := readResultAnnotations(results, columns, result_email_column)
+	if error_ != nil {
+		return summary, error_
+	}
+
+	csv_reader := csv.NewReader(original.File)
+	csv_reader.FieldsPerRecord = -1
+	csv_reader.LazyQuotes = true
+	csv_writer := csv.NewWriter(output)
+	empty := make([]string, len(columns))
+	for row := 0; ; row++ {
+		record, error_ := csv_reader.Read()
+		if error_ == io.EOF {
+			break
+		}
+		if error_ != nil {
+			return summary, errors.New("error reading from csv file: " + error_.Error())
+		}
+		if row == 0 && len(record) > 0 {
+			record[0] = strings.TrimPrefix(record[0], "\ufeff")
+		}
+		if row == 0 && original.HasHeaderRow {
+			error_ = csv_writer.Write(append(record, columns...))
+		} else {
+			summary.Rows++
+			var values []string
+			ok := false
+			if original.EmailAddressColumn <= len(record) {
+				values, ok = annotations[mergeKey(record[original.EmailAddressColumn-1])]
+			}
+			if ok {
+				summary.Matched++
+			} else {
+				values = empty
+				summary.Unmatched++
+			}
+			error_ = csv_writer.Write(append(record, values...))
+		}
+		if error_ != nil {
+			return summary, error_
+		}
+	}
+	csv_writer.Flush()
+	return summary, csv_writer.Error()
+}
+
+// mergeKey - key under which rows are joined
+func mergeKey(email string) string {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return ""
+	}
+	return strings.ToLower(NormalizeEmail(email))
+}
+
+// readResultAnnotations - values of the given columns of a result file, by
+// address (the first row of each address being kept)
+func readResultAnnotations(results io.Reader, columns []string, email_column int) (map[string][]string, error) {
+	csv_reader := csv.NewReader(results)
+	csv_reader.FieldsPerRecord = -1
+	csv_reader.LazyQuotes = true
+	header, error_ := csv_reader.Read()
+	if error_ == io.EOF {
+		return map[string][]string{}, nil
+	}
+	if error_ != nil {
+		return nil, errors.New("error reading results: " + error_.Error())
+	}
+
+	indexes := make([]int, len(columns))
+	for column_index, column := range columns {
+		column_key, column_phase := resultHeaderKey(column)
+		indexes[column_index] = -1
+		for index, name := range header {
+			if key, phase := resultHeaderKey(name); key == column_key && phase == column_phase {
+				indexes[column_index] = index
+				break
+			}
+		}
+		if indexes[column_index] < 0 {
+			return nil, fmt.Errorf("the results have no %q column", column)
+		}
+	}
+
+	annotations := make(map[string][]string)
+	for {
+		record, error_ := csv_reader.Read()
+		if error_ == io.EOF {
+			return annotations, nil
+		}
+		if error_ != nil {
+			return nil, errors.New("error reading results: " + error_.Error())
+		}
+		if email_column > len(record) {
+			continue
+		}
+		key := mergeKey(record[email_column-1])
+		if _, seen := annotations[key]; seen || key == "" {
+			continue
+		}
+		values := make([]string, len(indexes))
+		for column_index, index := range indexes {
+			if index < len(record) {
+				values[column_index] = record[index]
+			}
+		}
+		annotations[key] = values
+	}
+}
@@ -0,0 +1,55 @@
+package zerobouncego
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const sample_merge_original = "\ufeffName,Email\n" +
+	"Jane,jane@example.com\n" +
+	"John,john@example.com\n" +
+	"Jane again, JANE@Example.com \n" +
+	"Nobody,\n" +
+	"Jim,jim@example.com\n"
+
+const sample_merge_result = "Name,Email,ZB Status,ZB Sub Status,ZB Did You Mean,ZB Free Email\n" +
+	"Jane,jane@example.com,valid,,,true\n" +
+	"John,john@example.com,invalid,possible_typo,john@example.org,false\n"
+
+func TestMergeResults(t *testing.T) {
+	original := CsvFile{File: strings.NewReader(sample_merge_original), HasHeaderRow: true, EmailAddressColumn: 2}
+	output := &bytes.Buffer{}
+	summary, error_ := MergeResults(original, strings.NewReader(sample_merge_result), output, nil)
+	assert.Nil(t, error_)
+	assert.Equal(t, MergeSummary{Rows: 5, Matched: 3, Unmatched: 2}, summary)
+	assert.Equal(t, "Name,Email,ZB Status,ZB Sub Status,ZB Did You Mean\n"+
+		"Jane,jane@example.com,valid,,\n"+
+		"John,john@example.com,invalid,possible_typo,john@example.org\n"+
+		"Jane again,\" JANE@Example.com \",valid,,\n"+
+		"Nobody,,,,\n"+
+		"Jim,jim@example.com,,,\n", output.String())
+}
+
+func TestMergeResultsColumns(t *testing.T) {
+	original := CsvFile{File: strings.NewReader("john@example.com\njane@example.com\n"), EmailAddressColumn: 1}
+	output := &bytes.Buffer{}
+	summary, error_ := MergeResults(original, strings.NewReader(sample_merge_result), output, &MergeOptions{
+		Columns:                  []string{"zb_free_email", "ZB Status"},
+		ResultEmailAddressColumn: 2,
+	})
+	assert.Nil(t, error_)
+	assert.Equal(t, 2, summary.Matched)
+	assert.Equal(t, "john@example.com,false,invalid\njane@example.com,true,valid\n", output.String())
+
+	original.File = strings.NewReader("jane@example.com\n")
+	_, error_ = MergeResults(original, strings.NewReader(sample_merge_result), output, &MergeOptions{Columns: []string{"ZB Country"}})
+	if assert.NotNil(t, error_) {
+		assert.Contains(t, error_.Error(), "ZB Country")
+	}
+
+	_, error_ = MergeResults(CsvFile{File: strings.NewReader("")}, strings.NewReader(""), output, nil)
+	assert.NotNil(t, error_)
+}
@@ -0,0 +1,263 @@
+package zerobouncego
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const (
+	// default form/JSON field holding the email address
+	MIDDLEWARE_DEFAULT_EMAIL_FIELD = "email"
+	// default maximum size of a request body inspected by the middleware
+	MIDDLEWARE_DEFAULT_MAX_BODY_BYTES = 1 << 20
+)
+
+// errBodyTooLarge - the JSON body of a request exceeds MaxBodyBytes
+var errBodyTooLarge = errors.New("request body too large")
+
+// errInvalidJSONBody - the JSON body of a request is not a JSON object
+var errInvalidJSONBody = errors.New("invalid JSON body")
+
+type middlewareContextKey struct{}
+
+// EmailValidationResult - validation details attached to the request context by
+// `EmailValidationMiddleware`
+type EmailValidationResult struct {
+	Email     string
+	IPAddress string
+	Response  *ValidateResponse
+	Decision  PolicyDecision
+}
+
+// MiddlewareOptions - configuration of `EmailValidationMiddleware`
+type MiddlewareOptions struct {
+	// EmailField is the form field or top-level JSON key holding the address
+	// (defaults to "email")
+	EmailField string
+	// TrustedProxies lists IPs or CIDR ranges of proxies whose X-Forwarded-For
+	// header is honoured when deriving the client IP
+	TrustedProxies []string
+	// Policy decides whether the request proceeds (defaults to PolicyBalanced);
+	// a review verdict lets the request through with the result attached
+	Policy *Policy
+	// MaxBodyBytes limits the JSON body read (defaults to 1MB); larger bodies
+	// are answered with a 413
+	MaxBodyBytes int64
+	// RejectStatusCode is used when the policy rejects the address (defaults to 422)
+	RejectStatusCode int
+	// RejectBody is written on rejection (defaults to a JSON error payload)
+	RejectBody []byte
+	// RejectHandler, if set, replaces RejectStatusCode and RejectBody
+	RejectHandler func(http.ResponseWriter, *http.Request, *EmailValidationResult)
+	// RequireEmail rejects requests where the email field is missing or empty;
+	// otherwise such requests are passed through untouched
+	RequireEmail bool
+	// FailOpen lets requests through when the validation call fails; otherwise
+	// a 503 is returned
+	FailOpen bool
+	// Validate performs the validation (defaults to the package level `Validate`)
+	Validate func(email, ip_address string) (*ValidateResponse, error)
+}
+
+// ValidationResultFromContext - validation details attached by `EmailValidationMiddleware`
+func ValidationResultFromContext(ctx context.Context) (*EmailValidationResult, bool) {
+	result, ok := ctx.Value(middlewareContextKey{}).(*EmailValidationResult)
+	return result, ok
+}
+
+// EmailValidationMiddleware - wrap a handler such that the email field of
+// form or JSON request bodies is validated before the handler runs
+func EmailValidationMiddleware(options MiddlewareOptions) func(http.Handler) http.Handler {
+	if options.EmailField == "" {
+		options.EmailField = MIDDLEWARE_DEFAULT_EMAIL_FIELD
+	}
+	if options.Policy == nil {
+		options.Policy = PolicyBalanced()
+	}
+	if options.MaxBodyBytes <= 0 {
+		options.MaxBodyBytes = MIDDLEWARE_DEFAULT_MAX_BODY_BYTES
+	}
+	if options.RejectStatusCode == 0 {
+		options.RejectStatusCode = http.StatusUnprocessableEntity
+	}
+	if options.RejectBody == nil {
+		options.RejectBody = []byte(`{"success": false, "message": "email address rejected"}`)
+	}
+	if options.Validate == nil {
+		options.Validate = Validate
+	}
+	trusted_proxies := parseTrustedProxies(options.TrustedProxies)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			email, error_ := extractEmailField(request, options.EmailField, options.MaxBodyBytes)
+			if error_ == errBodyTooLarge {
+				http.Error(writer, "request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			if error_ == errInvalidJSONBody {
+				http.Error(writer, "invalid JSON body", http.StatusBadRequest)
+				return
+			}
+			if error_ != nil {
+				http.Error(writer, "could not read request body", http.StatusBadRequest)
+				return
+			}
+			if email == "" {
+				if options.RequireEmail {
+					rejectRequest(writer, request, options, &EmailValidationResult{})
+					return
+				}
+				next.ServeHTTP(writer, request)
+				return
+			}
+
+			result := &EmailValidationResult{
+				Email:     email,
+				IPAddress: clientIP(request, trusted_proxies),
+			}
+			result.Response, error_ = options.Validate(result.Email, result.IPAddress)
+			if error_ != nil {
+				if options.FailOpen {
+					next.ServeHTTP(writer, request)
+					return
+				}
+				http.Error(writer, "email validation unavailable", http.StatusServiceUnavailable)
+				return
+			}
+
+			result.Decision = options.Policy.Evaluate(result.Response)
+			if result.Decision.Verdict == VerdictReject {
+				rejectRequest(writer, request, options, result)
+				return
+			}
+			ctx := context.WithValue(request.Context(), middlewareContextKey{}, result)
+			next.ServeHTTP(writer, request.WithContext(ctx))
+		})
+	}
+}
+
+func rejectRequest(writer http.ResponseWriter, request *http.Request, options MiddlewareOptions, result *EmailValidationResult) {
+	if options.RejectHandler != nil {
+		options.RejectHandler(writer, request, result)
+		return
+	}
+	if json.Valid(options.RejectBody) {
+		writer.Header().Set("Content-Type", CONTENT_TYPE_JSON)
+	}
+	writer.WriteHeader(options.RejectStatusCode)
+	writer.Write(options.RejectBody)
+}
+
+// extractEmailField - read the email from a form or JSON body; JSON bodies are
+// restored such that the next handler can read them again, and rejected with
+// errBodyTooLarge beyond `max_body_bytes` or errInvalidJSONBody when not
+// holding a JSON object (empty bodies having no email)
+func extractEmailField(request *http.Request, field string, max_body_bytes int64) (string, error) {
+	media_type, _, _ := mime.ParseMediaType(request.Header.Get("Content-Type"))
+
+	if media_type == CONTENT_TYPE_JSON || strings.HasSuffix(media_type, "+json") {
+		if request.Body == nil {
+			return "", nil
+		}
+		body, error_ := io.ReadAll(io.LimitReader(request.Body, max_body_bytes+1))
+		if error_ != nil {
+			return "", error_
+		}
+		request.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), request.Body), request.Body}
+		if int64(len(body)) > max_body_bytes {
+			return "", errBodyTooLarge
+		}
+
+		if len(bytes.TrimSpace(body)) == 0 {
+			return "", nil
+		}
+		var payload map[string]interface{}
+		if json.Unmarshal(body, &payload) != nil {
+			return "", errInvalidJSONBody
+		}
+		if value, ok := payload[field].(string); ok {
+			return strings.TrimSpace(value), nil
+		}
+		return "", nil
+	}
+
+	// PostFormValue handles both url-encoded and multipart bodies, ignoring
+	// the query string; parsed values remain available to the next handler
+	// through request.Form
+	return strings.TrimSpace(request.PostFormValue(field)), nil
+}
+
+func parseTrustedProxies(values []string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if !strings.Contains(value, "/") {
+			if ip := net.ParseIP(value); ip != nil {
+				if ip.To4() != nil {
+					value += "/32"
+				} else {
+					value += "/128"
+				}
+			}
+		}
+		_, network, error_ := net.ParseCIDR(value)
+		if error_ == nil {
+			networks = append(networks, network)
+		}
+	}
+	return networks
+}
+
+func isTrustedProxy(ip net.IP, trusted_proxies []*net.IPNet) bool {
+	for _, network := range trusted_proxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP - derive the client IP of a request. X-Forwarded-For is only
+// honoured when the direct peer is one of the trusted proxies (IPs or CIDR
+// ranges), in which case the right-most address that is not a trusted proxy
+// is returned.
+func ClientIP(request *http.Request, trusted_proxies []string) string {
+	return clientIP(request, parseTrustedProxies(trusted_proxies))
+}
+
+func clientIP(request *http.Request, trusted_proxies []*net.IPNet) string {
+	remote_host, _, error_ := net.SplitHostPort(request.RemoteAddr)
+	if error_ != nil {
+		remote_host = request.RemoteAddr
+	}
+	remote_ip := net.ParseIP(remote_host)
+	if remote_ip == nil || !isTrustedProxy(remote_ip, trusted_proxies) {
+		return remote_host
+	}
+
+	var forwarded []string
+	for _, header_value := range request.Header.Values("X-Forwarded-For") {
+		forwarded = append(forwarded, strings.Split(header_value, ",")...)
+	}
+	for index := len(forwarded) - 1; index >= 0; index-- {
+		hop := net.ParseIP(strings.TrimSpace(forwarded[index]))
+		if hop == nil {
+			break
+		}
+		if !isTrustedProxy(hop, trusted_proxies) {
+			return hop.String()
+		}
+	}
+	return remote_host
+}
@@ -0,0 +1,221 @@
+package zerobouncego
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// echoHandler - records the attached validation result and echoes the body
+func echoHandler(t *testing.T, attached **EmailValidationResult) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		result, ok := ValidationResultFromContext(request.Context())
+		if ok {
+			*attached = result
+		}
+		body, error_ := io.ReadAll(request.Body)
+		assert.Nil(t, error_)
+		writer.WriteHeader(http.StatusOK)
+		writer.Write(body)
+	})
+}
+
+func TestMiddlewareJSONBodyAccepted(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	mockValidateRequest()
+
+	var attached *EmailValidationResult
+	handler := EmailValidationMiddleware(MiddlewareOptions{})(echoHandler(t, &attached))
+
+	body := `{"email": "valid@example.com", "name": "zero"}`
+	request := httptest.NewRequest("POST", "/signup", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json; charset=utf-8")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	// the next handler must still be able to read the body
+	assert.Equal(t, body, recorder.Body.String())
+	if assert.NotNil(t, attached) {
+		assert.Equal(t, "valid@example.com", attached.Email)
+		assert.Equal(t, S_VALID, attached.Response.Status)
+		assert.Equal(t, VerdictAccept, attached.Decision.Verdict)
+	}
+}
+
+func TestMiddlewareFormBodyRejected(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	mockValidateRequest()
+
+	var attached *EmailValidationResult
+	handler := EmailValidationMiddleware(MiddlewareOptions{
+		EmailField:       "contact",
+		RejectStatusCode: http.StatusBadRequest,
+		RejectBody:       []byte("bad email"),
+	})(echoHandler(t, &attached))
+
+	form := url.Values{"contact": {"toxic@example.com"}}
+	request := httptest.NewRequest("POST", "/contact", strings.NewReader(form.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, "bad email", recorder.Body.String())
+	assert.Nil(t, attached)
+}
+
+func TestMiddlewareMissingEmail(t *testing.T) {
+	validate_calls := 0
+	options := MiddlewareOptions{
+		Validate: func(email, ip_address string) (*ValidateResponse, error) {
+			validate_calls++
+			return &ValidateResponse{Status: S_VALID}, nil
+		},
+	}
+	var attached *EmailValidationResult
+	request := httptest.NewRequest("POST", "/signup", strings.NewReader(`{"name": "zero"}`))
+	request.Header.Set("Content-Type", "application/json")
+
+	recorder := httptest.NewRecorder()
+	EmailValidationMiddleware(options)(echoHandler(t, &attached)).ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	options.RequireEmail = true
+	request = httptest.NewRequest("POST", "/signup", strings.NewReader(`{"name": "zero"}`))
+	request.Header.Set("Content-Type", "application/json")
+	recorder = httptest.NewRecorder()
+	EmailValidationMiddleware(options)(echoHandler(t, &attached)).ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Equal(t, 0, validate_calls)
+}
+
+func TestMiddlewareBodyTooLarge(t *testing.T) {
+	validate_calls := 0
+	options := MiddlewareOptions{
+		MaxBodyBytes: 20,
+		Validate: func(email, ip_address string) (*ValidateResponse, error) {
+			validate_calls++
+			return &ValidateResponse{Status: S_VALID}, nil
+		},
+	}
+	var attached *EmailValidationResult
+	request := httptest.NewRequest("POST", "/signup", strings.NewReader(`{"email":"bad@example.com","padding":"xxxxxxxxxx"}`))
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	EmailValidationMiddleware(options)(echoHandler(t, &attached)).ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+	assert.Equal(t, 0, validate_calls)
+	assert.Nil(t, attached)
+
+	// the query string does not stand in for the posted field
+	request = httptest.NewRequest("POST", "/signup?email=valid@example.com", strings.NewReader("name=zero"))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	options.RequireEmail = true
+	recorder = httptest.NewRecorder()
+	EmailValidationMiddleware(options)(echoHandler(t, &attached)).ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Equal(t, 0, validate_calls)
+}
+
+func TestMiddlewareInvalidJSON(t *testing.T) {
+	validate_calls := 0
+	options := MiddlewareOptions{
+		Validate: func(email, ip_address string) (*ValidateResponse, error) {
+			validate_calls++
+			return &ValidateResponse{Status: S_VALID}, nil
+		},
+	}
+	for _, body := range []string{`{"email": "bad@example.com"`, `["bad@example.com"]`, `email=bad@example.com`} {
+		var attached *EmailValidationResult
+		request := httptest.NewRequest("POST", "/signup", strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		EmailValidationMiddleware(options)(echoHandler(t, &attached)).ServeHTTP(recorder, request)
+		assert.Equalf(t, http.StatusBadRequest, recorder.Code, "body %s", body)
+		assert.Nil(t, attached)
+	}
+	assert.Equal(t, 0, validate_calls)
+
+	// an empty body has no email, and is passed through
+	var attached *EmailValidationResult
+	request := httptest.NewRequest("POST", "/signup", strings.NewReader(""))
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	EmailValidationMiddleware(options)(echoHandler(t, &attached)).ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestMiddlewareValidationFailure(t *testing.T) {
+	options := MiddlewareOptions{
+		Validate: func(email, ip_address string) (*ValidateResponse, error) {
+			return nil, errors.New(sample_error_message)
+		},
+	}
+	form := url.Values{"email": {"valid@example.com"}}.Encode()
+	var attached *EmailValidationResult
+
+	request := httptest.NewRequest("POST", "/signup", strings.NewReader(form))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	EmailValidationMiddleware(options)(echoHandler(t, &attached)).ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+	options.FailOpen = true
+	request = httptest.NewRequest("POST", "/signup", strings.NewReader(form))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder = httptest.NewRecorder()
+	EmailValidationMiddleware(options)(echoHandler(t, &attached)).ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Nil(t, attached)
+}
+
+func TestMiddlewarePassesClientIP(t *testing.T) {
+	var received_ip string
+	options := MiddlewareOptions{
+		TrustedProxies: []string{"10.0.0.0/8"},
+		Validate: func(email, ip_address string) (*ValidateResponse, error) {
+			received_ip = ip_address
+			return &ValidateResponse{Status: S_VALID}, nil
+		},
+	}
+	var attached *EmailValidationResult
+	request := httptest.NewRequest("POST", "/signup", strings.NewReader(`{"email": "valid@example.com"}`))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Forwarded-For", "203.0.113.7, 10.1.1.1")
+	request.RemoteAddr = "10.0.0.2:5555"
+
+	EmailValidationMiddleware(options)(echoHandler(t, &attached)).ServeHTTP(httptest.NewRecorder(), request)
+	assert.Equal(t, "203.0.113.7", received_ip)
+	assert.Equal(t, "203.0.113.7", attached.IPAddress)
+}
+
+func TestClientIP(t *testing.T) {
+	request := httptest.NewRequest("GET", "/", nil)
+	request.RemoteAddr = "198.51.100.4:1234"
+	request.Header.Set("X-Forwarded-For", "203.0.113.7")
+
+	// untrusted peer: header is ignored
+	assert.Equal(t, "198.51.100.4", ClientIP(request, nil))
+	assert.Equal(t, "198.51.100.4", ClientIP(request, []string{"10.0.0.1"}))
+
+	// trusted peer: right-most untrusted hop wins
+	assert.Equal(t, "203.0.113.7", ClientIP(request, []string{"198.51.100.4"}))
+	request.Header.Set("X-Forwarded-For", "1.1.1.1, 203.0.113.7, 198.51.100.9")
+	assert.Equal(t, "203.0.113.7", ClientIP(request, []string{"198.51.100.0/24"}))
+
+	// every hop trusted: fall back to the peer
+	request.Header.Set("X-Forwarded-For", "198.51.100.9")
+	assert.Equal(t, "198.51.100.4", ClientIP(request, []string{"198.51.100.0/24"}))
+}
@@ -0,0 +1,147 @@
+package zerobouncego
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"mime/multipart"
+	"net/http"
+)
+
+// multipartUpload - multipart/form-data body of a file submission, streamed
+// from the csv file such that its contents are never held in memory
+type multipartUpload struct {
+	body           io.ReadCloser
+	content_type   string
+	content_length int64 // -1 when the size of the file cannot be known
+	progress       *progressReader
+
+	// digest of the file contents read so far, the copy being over once
+	// `copied` is closed
+	file   io.Reader
+	copied chan struct{}
+	hash   *fileDigest
+}
+
+// fileDigest - SHA-256 and size of the data written into it
+type fileDigest struct {
+	hash hash.Hash
+	size int64
+}
+
+func (f *fileDigest) Write(data []byte) (int, error) {
+	f.size += int64(len(data))
+	return f.hash.Write(data)
+}
+
+// newMultipartUpload - prepare the form of a file submission; the form
+// fields and the closing boundary are built upfront, while the file is
+// copied into the body through a pipe as the request is sent
+func newMultipartUpload(csv_file CsvFile, remove_duplicate bool, validationSendfile bool) (*multipartUpload, error) {
+	if csv_file.File == nil {
+		return nil, errors.New("csv file has no contents")
+	}
+
+	envelope := &bytes.Buffer{}
+	multipart_writer := multipart.NewWriter(envelope)
+	multipart_writer.WriteField("remove_duplicate", fmt.Sprintf("%v", remove_duplicate))
+	csv_file.writeFormFields(multipart_writer, validationSendfile)
+	_, error_ := multipart_writer.CreateFormFile("file", csv_file.FileName)
+	if error_ != nil {
+		return nil, errors.New("error creating multipart form: " + error_.Error())
+	}
+	prefix_length := envelope.Len()
+	error_ = multipart_writer.Close()
+	if error_ != nil {
+		return nil, errors.New("error populating multiform with file: " + error_.Error())
+	}
+	prefix := envelope.Bytes()[:prefix_length]
+	suffix := envelope.Bytes()[prefix_length:]
+
+	upload := &multipartUpload{
+		content_type: multipart_writer.FormDataContentType(), content_length: -1,
+		file: csv_file.File, copied: make(chan struct{}), hash: &fileDigest{hash: sha256.New()},
+	}
+	if file_size := remainingSize(csv_file.File); file_size >= 0 {
+		upload.content_length = int64(len(prefix)) + file_size + int64(len(suffix))
+	}
+
+	pipe_reader, pipe_writer := io.Pipe()
+	upload.body = pipe_reader
+	go func() {
+		defer close(upload.copied)
+		_, error_ := pipe_writer.Write(prefix)
+		if error_ == nil {
+			_, error_ = io.Copy(pipe_writer, io.TeeReader(csv_file.File, upload.hash))
+			if error_ != nil && !errors.Is(error_, io.ErrClosedPipe) {
+				error_ = errors.New("error reading from csv file: " + error_.Error())
+			}
+		}
+		if error_ == nil {
+			_, error_ = pipe_writer.Write(suffix)
+		}
+		pipe_writer.CloseWithError(error_)
+	}()
+	if csv_file.Progress != nil {
+		upload.progress = newProgressReader(pipe_reader, upload.content_length, csv_file.Progress)
+		upload.body = upload.progress
+	}
+	return upload, nil
+}
+
+// finish - make the final progress report, once the request is over
+func (m *multipartUpload) finish() {
+	if m.progress != nil {
+		m.progress.finish()
+	}
+}
+
+// digest - SHA-256 (hex-encoded) and size of the file, once the request is
+// over; the part of the file the server did not read is read here
+func (m *multipartUpload) digest() (string, int64, error) {
+	m.body.Close()
+	<-m.copied
+	_, error_ := io.Copy(m.hash, m.file)
+	if error_ != nil {
+		return "", 0, errors.New("error reading from csv file: " + error_.Error())
+	}
+	return hex.EncodeToString(m.hash.hash.Sum(nil)), m.hash.size, nil
+}
+
+// request - POST request sending the form to the given URL
+func (m *multipartUpload) request(url_to_access string) (*http.Request, error) {
+	request, error_ := http.NewRequest("POST", url_to_access, m.body)
+	if error_ != nil {
+		m.body.Close()
+		return nil, error_
+	}
+	request.Header.Set("Content-Type", m.content_type)
+	request.ContentLength = m.content_length
+	return request, nil
+}
+
+// remainingSize - number of bytes left to read from a seekable reader (-1
+// for other readers, or when seeking fails, eg: on pipes)
+func remainingSize(reader io.Reader) int64 {
+	seeker, ok := reader.(io.Seeker)
+	if !ok {
+		return -1
+	}
+	current, error_ := seeker.Seek(0, io.SeekCurrent)
+	if error_ != nil {
+		return -1
+	}
+	end, error_ := seeker.Seek(0, io.SeekEnd)
+	if error_ != nil {
+		return -1
+	}
+	_, error_ = seeker.Seek(current, io.SeekStart)
+	if error_ != nil {
+		return -1
+	}
+	return end - current
+}
@@ -0,0 +1,126 @@
+package zerobouncego
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// onlyReader - hides every method of a reader but Read (eg: Seek)
+type onlyReader struct{ io.Reader }
+
+// failingReader - reader failing after its contents
+type failingReader struct{ contents io.Reader }
+
+func (f *failingReader) Read(buffer []byte) (int, error) {
+	count, error_ := f.contents.Read(buffer)
+	if error_ == io.EOF {
+		return count, errors.New(sample_error_message)
+	}
+	return count, error_
+}
+
+// mockStreamedSendFile - mock POST/sendfile, recording the declared length,
+// the actual length of the body and the uploaded file
+func mockStreamedSendFile(t *testing.T, declared_length *int64, body_length *int64, file_contents *string) {
+	httpmock.RegisterResponder("POST", `=~^(.*)`+ENDPOINT_FILE_SEND+`(.*)\z`,
+		func(request *http.Request) (*http.Response, error) {
+			*declared_length = request.ContentLength
+			body, error_ := io.ReadAll(request.Body)
+			if error_ != nil {
+				return nil, error_
+			}
+			*body_length = int64(len(body))
+			request.Body = io.NopCloser(strings.NewReader(string(body)))
+			file, _, error_ := request.FormFile("file")
+			if error_ != nil {
+				return nil, error_
+			}
+			contents, _ := io.ReadAll(file)
+			*file_contents = string(contents)
+			assert.Equal(t, "false", request.FormValue("remove_duplicate"))
+			assert.Equal(t, "1", request.FormValue("email_address_column"))
+			return httpmock.NewStringResponse(201, send_file_response_200), nil
+		},
+	)
+}
+
+func TestFileSubmitStreamsSeekableFile(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	var declared_length, body_length int64
+	var file_contents string
+	mockStreamedSendFile(t, &declared_length, &body_length, &file_contents)
+
+	path_to_file := filepath.Join(t.TempDir(), "emails.csv")
+	contents := strings.Repeat("valid@example.com\n", 10000)
+	assert.Nil(t, os.WriteFile(path_to_file, []byte("email\n"+contents), 0644))
+	file, error_ := os.Open(path_to_file)
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	defer file.Close()
+	// only the remainder of a partially read file is sent
+	file.Seek(int64(len("email\n")), io.SeekStart)
+
+	response, error_ := BulkValidationSubmit(CsvFile{File: file, FileName: "emails.csv", EmailAddressColumn: 1}, false)
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	assert.Equal(t, testing_file_id, response.FileId)
+	assert.Equal(t, contents, file_contents)
+	assert.Equal(t, body_length, declared_length)
+}
+
+func TestFileSubmitStreamsUnseekableFile(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	var declared_length, body_length int64
+	var file_contents string
+	mockStreamedSendFile(t, &declared_length, &body_length, &file_contents)
+
+	contents := strings.Repeat("valid@example.com\n", 10000)
+	_, error_ := BulkValidationSubmit(CsvFile{File: onlyReader{strings.NewReader(contents)}, FileName: "emails.csv", EmailAddressColumn: 1}, false)
+	assert.Nil(t, error_)
+	assert.Equal(t, contents, file_contents)
+	assert.Equal(t, int64(-1), declared_length)
+	assert.Greater(t, body_length, int64(len(contents)))
+}
+
+func TestFileSubmitFileReadError(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	var declared_length, body_length int64
+	var file_contents string
+	mockStreamedSendFile(t, &declared_length, &body_length, &file_contents)
+
+	csv_file := CsvFile{File: &failingReader{strings.NewReader("valid@example.com\n")}, FileName: "emails.csv", EmailAddressColumn: 1}
+	_, error_ := BulkValidationSubmit(csv_file, false)
+	if assert.NotNil(t, error_) {
+		assert.Contains(t, error_.Error(), "error reading from csv file: "+sample_error_message)
+	}
+
+	_, error_ = BulkValidationSubmit(CsvFile{FileName: "emails.csv", EmailAddressColumn: 1}, false)
+	assert.NotNil(t, error_)
+}
+
+func TestRemainingSize(t *testing.T) {
+	reader := strings.NewReader("0123456789")
+	assert.Equal(t, int64(10), remainingSize(reader))
+	reader.Read(make([]byte, 4))
+	assert.Equal(t, int64(6), remainingSize(reader))
+	// the position is preserved
+	rest, _ := io.ReadAll(reader)
+	assert.Equal(t, "456789", string(rest))
+	assert.Equal(t, int64(-1), remainingSize(onlyReader{reader}))
+}
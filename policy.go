@@ -0,0 +1,306 @@
+package zerobouncego
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// PolicyVerdict - outcome of evaluating a validation result against a `Policy`
+type PolicyVerdict string
+
+const (
+	VerdictAccept PolicyVerdict = "accept"
+	VerdictReject PolicyVerdict = "reject"
+	VerdictReview PolicyVerdict = "review"
+)
+
+// names of the built-in policy presets
+const (
+	PolicyPresetStrict     = "strict"
+	PolicyPresetBalanced   = "balanced"
+	PolicyPresetPermissive = "permissive"
+)
+
+// PolicyRule - a single condition of a policy; every non-empty criterion must
+// match for the rule to fire. An empty `Statuses` or `SubStatuses` list matches
+// any value, while nil boolean criteria are ignored.
+type PolicyRule struct {
+	// Name identifies the rule in decisions and explanations
+	Name        string   `json:"name"`
+	Statuses    []string `json:"statuses,omitempty"`
+	SubStatuses []string `json:"sub_statuses,omitempty"`
+	FreeEmail   *bool    `json:"free_email,omitempty"`
+	// CatchallDomain matches against the `catchall_domain` field of the response;
+	// responses where the field is null never match a non-nil criterion
+	CatchallDomain *bool `json:"catchall_domain,omitempty"`
+	// HasDidYouMean matches responses that do (true) or do not (false) carry
+	// a `did_you_mean` suggestion
+	HasDidYouMean *bool `json:"has_did_you_mean,omitempty"`
+
+	Verdict PolicyVerdict `json:"verdict"`
+	// Reason is a short machine-friendly code reported when the rule fires
+	Reason string `json:"reason"`
+}
+
+// Policy - ordered list of rules mapping a `ValidateResponse` to a verdict.
+// Rules are evaluated in order and the first match decides the verdict; when
+// no rule matches, `DefaultVerdict` applies.
+type Policy struct {
+	Name           string        `json:"name"`
+	Rules          []PolicyRule  `json:"rules"`
+	DefaultVerdict PolicyVerdict `json:"default_verdict"`
+	DefaultReason  string        `json:"default_reason"`
+}
+
+// PolicyDecision - result of evaluating a policy
+type PolicyDecision struct {
+	Verdict PolicyVerdict `json:"verdict"`
+	// ReasonCodes holds the reason of every matching rule, in rule order; the
+	// first entry belongs to the rule that decided the verdict
+	ReasonCodes []string `json:"reason_codes"`
+	// Rule is the name of the deciding rule (empty when the default applied)
+	Rule string `json:"rule"`
+	// Explanation is a human readable description of why the verdict was given
+	Explanation string `json:"explanation"`
+}
+
+// IsAccepted - whether the decision allows mailing the address
+func (d PolicyDecision) IsAccepted() bool {
+	return d.Verdict == VerdictAccept
+}
+
+func isKnownVerdict(verdict PolicyVerdict) bool {
+	switch verdict {
+	case VerdictAccept, VerdictReject, VerdictReview:
+		return true
+	}
+	return false
+}
+
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches - whether the rule applies to the given validation response
+func (r *PolicyRule) Matches(response *ValidateResponse) bool {
+	if response == nil {
+		return false
+	}
+	if len(r.Statuses) > 0 && !containsFold(r.Statuses, response.Status) {
+		return false
+	}
+	if len(r.SubStatuses) > 0 && !containsFold(r.SubStatuses, response.SubStatus) {
+		return false
+	}
+	if r.FreeEmail != nil && *r.FreeEmail != response.FreeEmail {
+		return false
+	}
+	if r.CatchallDomain != nil {
+		if !response.CatchallDomain.Valid || response.CatchallDomain.Bool != *r.CatchallDomain {
+			return false
+		}
+	}
+	if r.HasDidYouMean != nil {
+		has_suggestion := response.DidYouMean.Valid && strings.TrimSpace(response.DidYouMean.String) != ""
+		if has_suggestion != *r.HasDidYouMean {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate - ensure the policy only uses known verdicts
+func (p *Policy) Validate() error {
+	if !isKnownVerdict(p.DefaultVerdict) {
+		return fmt.Errorf("policy %q: invalid default verdict %q", p.Name, p.DefaultVerdict)
+	}
+	for index, rule := range p.Rules {
+		if !isKnownVerdict(rule.Verdict) {
+			return fmt.Errorf("policy %q: rule %d (%s) has invalid verdict %q", p.Name, index, rule.Name, rule.Verdict)
+		}
+	}
+	return nil
+}
+
+// Evaluate - decide the verdict of a validation response
+func (p *Policy) Evaluate(response *ValidateResponse) PolicyDecision {
+	decision := PolicyDecision{ReasonCodes: []string{}}
+	var deciding_rule *PolicyRule
+
+	for index := range p.Rules {
+		rule := &p.Rules[index]
+		if !rule.Matches(response) {
+			continue
+		}
+		if rule.Reason != "" {
+			decision.ReasonCodes = append(decision.ReasonCodes, rule.Reason)
+		}
+		if deciding_rule == nil {
+			deciding_rule = rule
+		}
+	}
+
+	if deciding_rule == nil {
+		decision.Verdict = p.DefaultVerdict
+		if p.DefaultReason != "" {
+			decision.ReasonCodes = append(decision.ReasonCodes, p.DefaultReason)
+		}
+		decision.Explanation = fmt.Sprintf(
+			"no rule of policy %q matched %s; default verdict %q applied",
+			p.Name, describeResponse(response), p.DefaultVerdict,
+		)
+		return decision
+	}
+
+	decision.Verdict = deciding_rule.Verdict
+	decision.Rule = deciding_rule.Name
+	decision.Explanation = fmt.Sprintf(
+		"rule %q of policy %q matched %s; verdict %q",
+		deciding_rule.Name, p.Name, describeResponse(response), deciding_rule.Verdict,
+	)
+	return decision
+}
+
+// describeResponse - short description of the fields policies look at
+func describeResponse(response *ValidateResponse) string {
+	if response == nil {
+		return "an empty response"
+	}
+	description := fmt.Sprintf("status=%q sub_status=%q free_email=%t", response.Status, response.SubStatus, response.FreeEmail)
+	if response.CatchallDomain.Valid {
+		description += fmt.Sprintf(" catchall_domain=%t", response.CatchallDomain.Bool)
+	}
+	if response.DidYouMean.Valid && response.DidYouMean.String != "" {
+		description += fmt.Sprintf(" did_you_mean=%q", response.DidYouMean.String)
+	}
+	return description
+}
+
+// LoadPolicy - read a JSON encoded policy and validate it
+func LoadPolicy(reader io.Reader) (*Policy, error) {
+	policy := &Policy{}
+	decoder := json.NewDecoder(reader)
+	decoder.DisallowUnknownFields()
+	error_ := decoder.Decode(policy)
+	if error_ != nil {
+		return nil, fmt.Errorf("could not decode policy: %s", error_.Error())
+	}
+	if policy.DefaultVerdict == "" {
+		policy.DefaultVerdict = VerdictReview
+	}
+	error_ = policy.Validate()
+	if error_ != nil {
+		return nil, error_
+	}
+	return policy, nil
+}
+
+// LoadPolicyFromFile - read a JSON encoded policy from the given path
+func LoadPolicyFromFile(path_to_file string) (*Policy, error) {
+	file, error_ := os.Open(path_to_file)
+	if error_ != nil {
+		return nil, error_
+	}
+	defer file.Close()
+	return LoadPolicy(file)
+}
+
+func boolPointer(value bool) *bool {
+	return &value
+}
+
+// PolicyStrict - accept only plain valid addresses; everything uncertain is rejected
+func PolicyStrict() *Policy {
+	return &Policy{
+		Name: PolicyPresetStrict,
+		Rules: []PolicyRule{
+			{Name: "invalid", Statuses: []string{S_INVALID}, Verdict: VerdictReject, Reason: "invalid"},
+			{Name: "spamtrap", Statuses: []string{S_SPAMTRAP}, Verdict: VerdictReject, Reason: "spamtrap"},
+			{Name: "abuse", Statuses: []string{S_ABUSE}, Verdict: VerdictReject, Reason: "abuse"},
+			{Name: "do_not_mail", Statuses: []string{S_DO_NOT_MAIL}, Verdict: VerdictReject, Reason: "do_not_mail"},
+			{Name: "catch_all", Statuses: []string{S_CATCH_ALL}, Verdict: VerdictReject, Reason: "catch_all"},
+			{Name: "unknown", Statuses: []string{S_UNKNOWN}, Verdict: VerdictReject, Reason: "unknown"},
+			{
+				Name: "role_based_accept_all", Statuses: []string{S_VALID}, SubStatuses: []string{SS_ROLE_BASED_ACCEPT_ALL},
+				Verdict: VerdictReject, Reason: "role_based",
+			},
+			{
+				Name: "did_you_mean", Statuses: []string{S_VALID}, HasDidYouMean: boolPointer(true),
+				Verdict: VerdictReview, Reason: "possible_typo",
+			},
+			{Name: "valid", Statuses: []string{S_VALID}, Verdict: VerdictAccept, Reason: "valid"},
+		},
+		DefaultVerdict: VerdictReject,
+		DefaultReason:  "unrecognized_status",
+	}
+}
+
+// PolicyBalanced - reject clearly bad addresses and send uncertain ones to review
+func PolicyBalanced() *Policy {
+	return &Policy{
+		Name: PolicyPresetBalanced,
+		Rules: []PolicyRule{
+			{
+				Name: "possible_typo", Statuses: []string{S_INVALID}, SubStatuses: []string{SS_POSSIBLE_TYPO},
+				Verdict: VerdictReview, Reason: "possible_typo",
+			},
+			{Name: "invalid", Statuses: []string{S_INVALID}, Verdict: VerdictReject, Reason: "invalid"},
+			{Name: "spamtrap", Statuses: []string{S_SPAMTRAP}, Verdict: VerdictReject, Reason: "spamtrap"},
+			{Name: "abuse", Statuses: []string{S_ABUSE}, Verdict: VerdictReject, Reason: "abuse"},
+			{
+				Name: "role_based", Statuses: []string{S_DO_NOT_MAIL},
+				SubStatuses: []string{SS_ROLE_BASED, SS_ROLE_BASED_CATCH_ALL},
+				Verdict:     VerdictReview, Reason: "role_based",
+			},
+			{Name: "do_not_mail", Statuses: []string{S_DO_NOT_MAIL}, Verdict: VerdictReject, Reason: "do_not_mail"},
+			{Name: "catch_all", Statuses: []string{S_CATCH_ALL}, Verdict: VerdictReview, Reason: "catch_all"},
+			{Name: "unknown", Statuses: []string{S_UNKNOWN}, Verdict: VerdictReview, Reason: "unknown"},
+			{Name: "valid", Statuses: []string{S_VALID}, Verdict: VerdictAccept, Reason: "valid"},
+		},
+		DefaultVerdict: VerdictReview,
+		DefaultReason:  "unrecognized_status",
+	}
+}
+
+// PolicyPermissive - reject only addresses that are certainly harmful to mail
+func PolicyPermissive() *Policy {
+	return &Policy{
+		Name: PolicyPresetPermissive,
+		Rules: []PolicyRule{
+			{
+				Name: "possible_typo", Statuses: []string{S_INVALID}, SubStatuses: []string{SS_POSSIBLE_TYPO},
+				Verdict: VerdictReview, Reason: "possible_typo",
+			},
+			{Name: "invalid", Statuses: []string{S_INVALID}, Verdict: VerdictReject, Reason: "invalid"},
+			{Name: "spamtrap", Statuses: []string{S_SPAMTRAP}, Verdict: VerdictReject, Reason: "spamtrap"},
+			{
+				Name: "harmful", Statuses: []string{S_DO_NOT_MAIL},
+				SubStatuses: []string{SS_DISPOSABLE, SS_TOXIC, SS_GLOBAL_SUPPRESSION, SS_POSSIBLE_TRAP},
+				Verdict:     VerdictReject, Reason: "do_not_mail",
+			},
+		},
+		DefaultVerdict: VerdictAccept,
+		DefaultReason:  "not_rejected",
+	}
+}
+
+// PolicyPreset - get a built-in policy by name (strict, balanced, permissive)
+func PolicyPreset(name string) (*Policy, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case PolicyPresetStrict:
+		return PolicyStrict(), nil
+	case PolicyPresetBalanced:
+		return PolicyBalanced(), nil
+	case PolicyPresetPermissive:
+		return PolicyPermissive(), nil
+	}
+	return nil, fmt.Errorf("unknown policy preset %q", name)
+}
@@ -0,0 +1,110 @@
+package zerobouncego
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/guregu/null.v4"
+)
+
+// mockedResponse - decode the mocked validation payload of an example email
+func mockedResponse(t *testing.T, email string) *ValidateResponse {
+	response := &ValidateResponse{}
+	error_ := json.Unmarshal([]byte(MOCK_VALIDATE_RESPONSE[email]), response)
+	if error_ != nil {
+		t.Fatalf("could not decode mock for %s: %s", email, error_.Error())
+	}
+	return response
+}
+
+func TestPolicyPresetsVerdicts(t *testing.T) {
+	test_cases := []struct {
+		email      string
+		strict     PolicyVerdict
+		balanced   PolicyVerdict
+		permissive PolicyVerdict
+	}{
+		{"valid@example.com", VerdictAccept, VerdictAccept, VerdictAccept},
+		{"invalid@example.com", VerdictReject, VerdictReject, VerdictReject},
+		{"possible_typo@example.com", VerdictReject, VerdictReview, VerdictReview},
+		{"catch_all@example.com", VerdictReject, VerdictReview, VerdictAccept},
+		{"role_based@example.com", VerdictReject, VerdictReview, VerdictAccept},
+		{"toxic@example.com", VerdictReject, VerdictReject, VerdictReject},
+		{"unknown@example.com", VerdictReject, VerdictReview, VerdictAccept},
+		{"spamtrap@example.com", VerdictReject, VerdictReject, VerdictReject},
+		{"role_based_accept_all@example.com", VerdictReject, VerdictAccept, VerdictAccept},
+	}
+
+	for _, test_case := range test_cases {
+		response := mockedResponse(t, test_case.email)
+		assert.Equalf(t, test_case.strict, PolicyStrict().Evaluate(response).Verdict, "strict: %s", test_case.email)
+		assert.Equalf(t, test_case.balanced, PolicyBalanced().Evaluate(response).Verdict, "balanced: %s", test_case.email)
+		assert.Equalf(t, test_case.permissive, PolicyPermissive().Evaluate(response).Verdict, "permissive: %s", test_case.email)
+	}
+}
+
+func TestPolicyDecisionExplainsRule(t *testing.T) {
+	decision := PolicyBalanced().Evaluate(mockedResponse(t, "catch_all@example.com"))
+	assert.Equal(t, "catch_all", decision.Rule)
+	assert.Equal(t, []string{"catch_all"}, decision.ReasonCodes)
+	assert.Contains(t, decision.Explanation, `rule "catch_all"`)
+	assert.Contains(t, decision.Explanation, `status="catch-all"`)
+
+	decision = PolicyPermissive().Evaluate(mockedResponse(t, "valid@example.com"))
+	assert.Equal(t, "", decision.Rule)
+	assert.Equal(t, []string{"not_rejected"}, decision.ReasonCodes)
+	assert.Contains(t, decision.Explanation, "default verdict")
+}
+
+func TestPolicyDidYouMeanRule(t *testing.T) {
+	response := &ValidateResponse{Status: S_VALID, DidYouMean: null.StringFrom("user@gmail.com")}
+	decision := PolicyStrict().Evaluate(response)
+	assert.Equal(t, VerdictReview, decision.Verdict)
+	assert.Equal(t, []string{"possible_typo", "valid"}, decision.ReasonCodes)
+}
+
+func TestLoadPolicy(t *testing.T) {
+	policy_json := `{
+		"name": "signup",
+		"rules": [
+			{"name": "free", "statuses": ["valid"], "free_email": true, "verdict": "review", "reason": "free_email"},
+			{"name": "catch_all_domain", "catchall_domain": true, "verdict": "reject", "reason": "catch_all"},
+			{"name": "valid", "statuses": ["valid"], "verdict": "accept", "reason": "valid"}
+		],
+		"default_verdict": "reject"
+	}`
+	policy, error_ := LoadPolicy(strings.NewReader(policy_json))
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	assert.Equal(t, "signup", policy.Name)
+	assert.Len(t, policy.Rules, 3)
+
+	free_response := &ValidateResponse{Status: S_VALID, FreeEmail: true}
+	assert.Equal(t, VerdictReview, policy.Evaluate(free_response).Verdict)
+	assert.Equal(t, "free", policy.Evaluate(free_response).Rule)
+
+	catchall_response := &ValidateResponse{Status: S_CATCH_ALL, CatchallDomain: null.BoolFrom(true)}
+	assert.Equal(t, VerdictReject, policy.Evaluate(catchall_response).Verdict)
+
+	assert.Equal(t, VerdictAccept, policy.Evaluate(&ValidateResponse{Status: S_VALID}).Verdict)
+	assert.Equal(t, VerdictReject, policy.Evaluate(&ValidateResponse{Status: S_UNKNOWN}).Verdict)
+}
+
+func TestLoadPolicyErrors(t *testing.T) {
+	_, error_ := LoadPolicy(strings.NewReader(`{"rules": [{"name": "x", "verdict": "maybe"}]}`))
+	assert.NotNil(t, error_)
+	assert.Contains(t, error_.Error(), "maybe")
+
+	_, error_ = LoadPolicy(strings.NewReader(`{"unexpected": true}`))
+	assert.NotNil(t, error_)
+
+	_, error_ = PolicyPreset("lenient")
+	assert.NotNil(t, error_)
+
+	policy, error_ := PolicyPreset(" Strict ")
+	assert.Nil(t, error_)
+	assert.Equal(t, PolicyPresetStrict, policy.Name)
+}
@@ -0,0 +1,274 @@
+package zerobouncego
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// length limits from RFC 5321 (section 4.5.3.1)
+const (
+	MAX_EMAIL_LENGTH        = 254
+	MAX_LOCAL_PART_LENGTH   = 64
+	MAX_DOMAIN_LENGTH       = 253
+	MAX_DOMAIN_LABEL_LENGTH = 63
+)
+
+// reasons for which an address fails the local pre-check
+const (
+	PrecheckReasonEmpty             = "empty"
+	PrecheckReasonMissingAtSign     = "missing_at_sign"
+	PrecheckReasonInvalidLocalPart  = "invalid_local_part"
+	PrecheckReasonLocalPartTooLong  = "local_part_too_long"
+	PrecheckReasonInvalidDomain     = "invalid_domain"
+	PrecheckReasonDomainTooLong     = "domain_too_long"
+	PrecheckReasonAddressTooLong    = "address_too_long"
+	PrecheckReasonInvalidCharacters = "invalid_characters"
+)
+
+// SYNTAX_PRECHECK - when enabled, Validate, ValidateWithTimeout and
+// ValidateBatch check addresses locally first: malformed addresses get a
+// synthetic invalid/failed_syntax_check result without spending a credit and
+// well-formed ones are sent in their normalized form
+var SYNTAX_PRECHECK = false
+
+// PrecheckResult - outcome of the offline syntax check of an address
+type PrecheckResult struct {
+	// Original is the address as given
+	Original string
+	// Normalized is the trimmed address with a lowercase, punycode domain
+	// (equals the trimmed input when the address could not be split)
+	Normalized string
+	Account    string
+	Domain     string
+	Valid      bool
+	// Reason is one of the PrecheckReason... values when the check failed
+	Reason string
+}
+
+// Response - synthetic invalid/failed_syntax_check validation response of an
+// address failing the pre-check (nil if the address passed)
+func (p PrecheckResult) Response() *ValidateResponse {
+	if p.Valid {
+		return nil
+	}
+	return &ValidateResponse{
+		Address:          p.Original,
+		SubmittedAddress: p.Original,
+		Status:           S_INVALID,
+		SubStatus:        SS_FAILED_SYNTAX_CHECK,
+		Account:          p.Account,
+		Domain:           p.Domain,
+		RawProcessedAt:   time.Now().UTC().Format(DATE_TIME_FORMAT),
+	}
+}
+
+// NormalizeEmail - trim an address and lowercase its domain, converting it to
+// punycode; the local part is kept as is
+func NormalizeEmail(email string) string {
+	return PrecheckEmail(email).Normalized
+}
+
+// PrecheckEmail - check the syntax (RFC 5321/5322, with RFC 6531 UTF-8 local
+// parts) and length limits of an address without any network call
+func PrecheckEmail(email string) PrecheckResult {
+	result := PrecheckResult{Original: email}
+	trimmed := strings.TrimSpace(email)
+	result.Normalized = trimmed
+
+	if trimmed == "" {
+		result.Reason = PrecheckReasonEmpty
+		return result
+	}
+	if !utf8.ValidString(trimmed) {
+		result.Reason = PrecheckReasonInvalidCharacters
+		return result
+	}
+	local_part, domain, ok := splitEmail(trimmed)
+	if !ok {
+		result.Reason = PrecheckReasonMissingAtSign
+		return result
+	}
+	result.Account = local_part
+
+	if isAddressLiteral(domain) {
+		result.Domain = strings.ToLower(domain)
+	} else {
+		ascii_domain, error_ := DomainToASCII(domain)
+		if error_ != nil {
+			result.Reason = PrecheckReasonInvalidDomain
+			return result
+		}
+		result.Domain = ascii_domain
+	}
+	result.Normalized = result.Account + "@" + result.Domain
+
+	switch {
+	case len(result.Account) == 0 || !isValidLocalPart(result.Account):
+		result.Reason = PrecheckReasonInvalidLocalPart
+	case len(result.Account) > MAX_LOCAL_PART_LENGTH:
+		result.Reason = PrecheckReasonLocalPartTooLong
+	case len(result.Domain) > MAX_DOMAIN_LENGTH:
+		result.Reason = PrecheckReasonDomainTooLong
+	case !isValidDomain(result.Domain):
+		result.Reason = PrecheckReasonInvalidDomain
+	case len(result.Normalized) > MAX_EMAIL_LENGTH:
+		result.Reason = PrecheckReasonAddressTooLong
+	default:
+		result.Valid = true
+	}
+	return result
+}
+
+func isAtext(r rune) bool {
+	if r >= 0x80 {
+		// RFC 6531 allows UTF-8 in local parts
+		return true
+	}
+	if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+		return true
+	}
+	return strings.ContainsRune("!#$%&'*+-/=?^_`{|}~", r)
+}
+
+func isValidLocalPart(local_part string) bool {
+	if len(local_part) >= 2 && local_part[0] == '"' && local_part[len(local_part)-1] == '"' {
+		return isValidQuotedString(local_part[1 : len(local_part)-1])
+	}
+	// dot-atom: atext separated by single dots
+	for _, atom := range strings.Split(local_part, ".") {
+		if atom == "" {
+			return false
+		}
+		for _, r := range atom {
+			if !isAtext(r) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isValidQuotedString(content string) bool {
+	escaped := false
+	for _, r := range content {
+		if escaped {
+			if r < 0x20 && r != '\t' || r == 0x7f {
+				return false
+			}
+			escaped = false
+			continue
+		}
+		switch {
+		case r == '\\':
+			escaped = true
+		case r == '"':
+			return false
+		case r < 0x20 && r != '\t' || r == 0x7f:
+			return false
+		}
+	}
+	return !escaped
+}
+
+func isAddressLiteral(domain string) bool {
+	return strings.HasPrefix(domain, "[") && strings.HasSuffix(domain, "]")
+}
+
+func isValidDomain(domain string) bool {
+	if isAddressLiteral(domain) {
+		literal := domain[1 : len(domain)-1]
+		if len(literal) > 5 && strings.EqualFold(literal[:5], "ipv6:") {
+			ip := net.ParseIP(literal[5:])
+			return ip != nil && ip.To4() == nil
+		}
+		ip := net.ParseIP(literal)
+		return ip != nil && ip.To4() != nil && !strings.Contains(literal, ":")
+	}
+
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return false
+	}
+	for _, label := range labels {
+		if len(label) == 0 || len(label) > MAX_DOMAIN_LABEL_LENGTH {
+			return false
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for index := 0; index < len(label); index++ {
+			character := label[index]
+			if !(character >= 'a' && character <= 'z' || character >= '0' && character <= '9' || character == '-') {
+				return false
+			}
+		}
+	}
+	top_level_domain := labels[len(labels)-1]
+	return strings.Trim(top_level_domain, "0123456789") != ""
+}
+
+// PrecheckCsvFile - pre-check the email column of a csv file before a bulk
+// submission. A copy of `csv_file` is returned whose contents hold the
+// header (if any) and the rows passing the check, with their email normalized;
+// the rows that failed are reported by their pre-check result. The whole
+// file is read in memory.
+func PrecheckCsvFile(csv_file CsvFile) (*CsvFile, []PrecheckResult, error) {
+	if csv_file.File == nil {
+		return nil, nil, errors.New("csv file has no contents")
+	}
+	if csv_file.EmailAddressColumn < 1 {
+		return nil, nil, fmt.Errorf("invalid email address column %d", csv_file.EmailAddressColumn)
+	}
+	email_index := csv_file.EmailAddressColumn - 1
+
+	reader := csv.NewReader(csv_file.File)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	output := &bytes.Buffer{}
+	writer := csv.NewWriter(output)
+	var rejected []PrecheckResult
+	is_header := csv_file.HasHeaderRow
+
+	for {
+		record, error_ := reader.Read()
+		if error_ == io.EOF {
+			break
+		}
+		if error_ != nil {
+			return nil, nil, errors.New("error reading from csv file: " + error_.Error())
+		}
+		if is_header {
+			is_header = false
+			writer.Write(record)
+			continue
+		}
+
+		email := ""
+		if email_index < len(record) {
+			email = record[email_index]
+		}
+		result := PrecheckEmail(email)
+		if !result.Valid {
+			rejected = append(rejected, result)
+			continue
+		}
+		record[email_index] = result.Normalized
+		writer.Write(record)
+	}
+
+	writer.Flush()
+	if error_ := writer.Error(); error_ != nil {
+		return nil, nil, error_
+	}
+	checked_file := csv_file
+	checked_file.File = output
+	return &checked_file, rejected, nil
+}
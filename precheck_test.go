@@ -0,0 +1,137 @@
+package zerobouncego
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrecheckEmailValid(t *testing.T) {
+	test_cases := map[string]string{
+		"valid@example.com":               "valid@example.com",
+		"  Valid@Example.COM \n":          "Valid@example.com",
+		"first.last+tag@sub.example.org":  "first.last+tag@sub.example.org",
+		`"john doe"@example.com`:          `"john doe"@example.com`,
+		`"at@sign"@example.com`:           `"at@sign"@example.com`,
+		"user@bücher.de":                  "user@xn--bcher-kva.de",
+		"用户@例子.广告":                        "用户@xn--fsqu00a.xn--4rr70v",
+		"user@[192.168.0.1]":              "user@[192.168.0.1]",
+		"user@[IPv6:2001:db8::1]":         "user@[ipv6:2001:db8::1]",
+		"o'reilly@example.co.uk":          "o'reilly@example.co.uk",
+		"x@xn--bcher-kva.de":              "x@xn--bcher-kva.de",
+		"user@bu\u0308cher.de":            "user@xn--bcher-kva.de",
+		strings.Repeat("a", 64) + "@a.io": strings.Repeat("a", 64) + "@a.io",
+	}
+	for email, normalized := range test_cases {
+		result := PrecheckEmail(email)
+		assert.Truef(t, result.Valid, "%s: %s", email, result.Reason)
+		assert.Equalf(t, normalized, result.Normalized, "email %s", email)
+		assert.Nil(t, result.Response())
+	}
+}
+
+func TestPrecheckEmailInvalid(t *testing.T) {
+	test_cases := map[string]string{
+		"":                                PrecheckReasonEmpty,
+		"   ":                             PrecheckReasonEmpty,
+		"plainaddress":                    PrecheckReasonMissingAtSign,
+		"@example.com":                    PrecheckReasonInvalidLocalPart,
+		".leading@example.com":            PrecheckReasonInvalidLocalPart,
+		"double..dot@example.com":         PrecheckReasonInvalidLocalPart,
+		"spa ce@example.com":              PrecheckReasonInvalidLocalPart,
+		`"unterminated@example.com`:       PrecheckReasonInvalidLocalPart,
+		strings.Repeat("a", 65) + "@a.io": PrecheckReasonLocalPartTooLong,
+		"user@":                           PrecheckReasonInvalidDomain,
+		"user@localhost":                  PrecheckReasonInvalidDomain,
+		"user@-example.com":               PrecheckReasonInvalidDomain,
+		"user@exa_mple.com":               PrecheckReasonInvalidDomain,
+		"user@example..com":               PrecheckReasonInvalidDomain,
+		"user@example.123":                PrecheckReasonInvalidDomain,
+		"user@[300.1.1.1]":                PrecheckReasonInvalidDomain,
+		"user@xn--bcher-kv!.de":           PrecheckReasonInvalidDomain,
+		"user@xn--a.de":                   PrecheckReasonInvalidDomain,
+		"user@" + strings.Repeat("a", 64) + ".com":                                                     PrecheckReasonInvalidDomain,
+		"user@" + strings.Repeat(strings.Repeat("a", 60)+".", 5) + "com":                               PrecheckReasonDomainTooLong,
+		strings.Repeat("a", 64) + "@" + strings.Repeat(strings.Repeat("a", 60)+".", 3) + "example.com": PrecheckReasonAddressTooLong,
+		"bad\xffbyte@example.com":                                                                      PrecheckReasonInvalidCharacters,
+	}
+	for email, reason := range test_cases {
+		result := PrecheckEmail(email)
+		assert.Falsef(t, result.Valid, "email %s", email)
+		assert.Equalf(t, reason, result.Reason, "email %s", email)
+
+		response := result.Response()
+		if assert.NotNil(t, response) {
+			assert.Equal(t, S_INVALID, response.Status)
+			assert.Equal(t, SS_FAILED_SYNTAX_CHECK, response.SubStatus)
+			assert.Equal(t, email, response.Address)
+		}
+	}
+}
+
+func TestValidateWithPrecheck(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	mockValidateRequest()
+	SYNTAX_PRECHECK = true
+	defer func() { SYNTAX_PRECHECK = false }()
+
+	response, error_ := Validate("not-an-email", SANDBOX_IP)
+	assert.Nil(t, error_)
+	assert.Equal(t, SS_FAILED_SYNTAX_CHECK, response.SubStatus)
+	assert.Equal(t, 0, httpmock.GetTotalCallCount())
+
+	response, error_ = Validate(" valid@EXAMPLE.com ", SANDBOX_IP)
+	assert.Nil(t, error_)
+	assert.Equal(t, S_VALID, response.Status)
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+}
+
+func TestValidateBatchWithPrecheck(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	mockBatchValidateRequest()
+	SYNTAX_PRECHECK = true
+	defer func() { SYNTAX_PRECHECK = false }()
+
+	response, error_ := ValidateBatch([]EmailToValidate{{EmailAddress: "bad@"}, {EmailAddress: "nope"}})
+	assert.Nil(t, error_)
+	assert.Len(t, response.EmailBatch, 2)
+	assert.Equal(t, 0, httpmock.GetTotalCallCount())
+
+	response, error_ = ValidateBatch([]EmailToValidate{
+		{EmailAddress: "valid@example.com"}, {EmailAddress: "bad@"}, {EmailAddress: "toxic@Example.com"},
+	})
+	assert.Nil(t, error_)
+	assert.Len(t, response.EmailBatch, 3)
+	assert.Len(t, response.Errors, 0)
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+	assert.Equal(t, SS_FAILED_SYNTAX_CHECK, response.EmailBatch[1].SubStatus)
+}
+
+func TestPrecheckCsvFile(t *testing.T) {
+	csv_file := CsvFile{
+		File:               strings.NewReader("id,email\n1,valid@EXAMPLE.com\n2,broken\n3,\n4,user@bücher.de\n"),
+		FileName:           "emails.csv",
+		HasHeaderRow:       true,
+		EmailAddressColumn: 2,
+	}
+	checked_file, rejected, error_ := PrecheckCsvFile(csv_file)
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	contents, _ := io.ReadAll(checked_file.File)
+	assert.Equal(t, "id,email\n1,valid@example.com\n4,user@xn--bcher-kva.de\n", string(contents))
+	assert.Equal(t, csv_file.FileName, checked_file.FileName)
+	assert.Len(t, rejected, 2)
+	assert.Equal(t, PrecheckReasonMissingAtSign, rejected[0].Reason)
+	assert.Equal(t, PrecheckReasonEmpty, rejected[1].Reason)
+
+	_, _, error_ = PrecheckCsvFile(CsvFile{File: strings.NewReader(""), EmailAddressColumn: 0})
+	assert.NotNil(t, error_)
+}
@@ -0,0 +1,143 @@
+package zerobouncego
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// PROGRESS_REPORT_INTERVAL - minimum delay between two progress reports of a
+// transfer (the final report is always made)
+var PROGRESS_REPORT_INTERVAL = 250 * time.Millisecond
+
+// TransferProgress - state of a file upload or download
+type TransferProgress struct {
+	BytesTransferred int64
+	// TotalBytes is -1 when the size of the transfer is not known
+	TotalBytes int64
+	// Rate is the average transfer rate so far, in bytes per second
+	Rate float64
+	// ETA is the estimated remaining time, -1 when it cannot be estimated
+	ETA     time.Duration
+	Elapsed time.Duration
+	// Done is set on the final report, made once the transfer ended
+	Done bool
+}
+
+// Percentage - completion of the transfer, from 0 to 100 (-1 when the total
+// is not known)
+func (t TransferProgress) Percentage() float64 {
+	if t.TotalBytes < 0 {
+		return -1
+	}
+	if t.TotalBytes == 0 {
+		return 100
+	}
+	return float64(t.BytesTransferred) * 100 / float64(t.TotalBytes)
+}
+
+// ProgressFunc - receives the progress of a transfer; it is called from the
+// goroutine performing the transfer, and should return quickly
+type ProgressFunc func(progress TransferProgress)
+
+// progressReader - reader reporting how much of it was read
+type progressReader struct {
+	reader      io.Reader
+	total_bytes int64
+	report      ProgressFunc
+	now         func() time.Time
+
+	mutex       sync.Mutex
+	resumed_at  int64 // bytes transferred before this reader, not counted in the rate
+	transferred int64
+	started_at  time.Time
+	reported_at time.Time
+	done        bool
+}
+
+// newProgressReader - wrap a reader of `total_bytes` (-1 if unknown) such that
+// its reads are reported
+func newProgressReader(reader io.Reader, total_bytes int64, report ProgressFunc) *progressReader {
+	if total_bytes < 0 {
+		total_bytes = -1
+	}
+	started_at := time.Now()
+	return &progressReader{
+		reader: reader, total_bytes: total_bytes, report: report, now: time.Now,
+		started_at: started_at, reported_at: started_at,
+	}
+}
+
+func (p *progressReader) Read(buffer []byte) (int, error) {
+	count, error_ := p.reader.Read(buffer)
+	p.mutex.Lock()
+	p.transferred += int64(count)
+	now := p.now()
+	finished := error_ == io.EOF
+	should_report := !p.done && (finished || now.Sub(p.reported_at) >= PROGRESS_REPORT_INTERVAL)
+	if should_report {
+		p.reported_at = now
+		p.done = finished
+	}
+	progress := p.progress(now, finished)
+	p.mutex.Unlock()
+
+	if should_report {
+		p.report(progress)
+	}
+	return count, error_
+}
+
+// Close - close the wrapped reader, if it can be closed
+func (p *progressReader) Close() error {
+	if closer, ok := p.reader.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// resume - account for bytes transferred before this reader, eg: by a
+// previous attempt of a download
+func (p *progressReader) resume(transferred int64) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.resumed_at = transferred
+	p.transferred = transferred
+}
+
+// finish - make the final report, if the end of the reader was not reached
+// (eg: the transfer failed or its size was unknown)
+func (p *progressReader) finish() {
+	p.mutex.Lock()
+	if p.done {
+		p.mutex.Unlock()
+		return
+	}
+	p.done = true
+	progress := p.progress(p.now(), true)
+	p.mutex.Unlock()
+	p.report(progress)
+}
+
+func (p *progressReader) progress(now time.Time, done bool) TransferProgress {
+	progress := TransferProgress{
+		BytesTransferred: p.transferred,
+		TotalBytes:       p.total_bytes,
+		Elapsed:          now.Sub(p.started_at),
+		ETA:              -1,
+		Done:             done,
+	}
+	if seconds := progress.Elapsed.Seconds(); seconds > 0 {
+		progress.Rate = float64(p.transferred-p.resumed_at) / seconds
+	}
+	if done && p.transferred == p.total_bytes {
+		progress.ETA = 0
+	} else if p.total_bytes >= 0 && progress.Rate > 0 {
+		remaining := p.total_bytes - p.transferred
+		if remaining < 0 {
+			remaining = 0
+		}
+		progress.ETA = time.Duration(float64(remaining) / progress.Rate * float64(time.Second))
+	}
+	return progress
+}
@@ -0,0 +1,135 @@
+package zerobouncego
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// progressRecorder - collects the reports of a transfer
+type progressRecorder struct {
+	mutex   sync.Mutex
+	reports []TransferProgress
+}
+
+func (p *progressRecorder) record(progress TransferProgress) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.reports = append(p.reports, progress)
+}
+
+func (p *progressRecorder) last() TransferProgress {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if len(p.reports) == 0 {
+		return TransferProgress{}
+	}
+	return p.reports[len(p.reports)-1]
+}
+
+func TestProgressReaderReports(t *testing.T) {
+	recorder := &progressRecorder{}
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracker := newProgressReader(strings.NewReader(strings.Repeat("x", 1000)), 1000, recorder.record)
+	tracker.now = func() time.Time { return clock }
+	tracker.started_at, tracker.reported_at = clock, clock
+
+	buffer := make([]byte, 100)
+	for {
+		clock = clock.Add(100 * time.Millisecond)
+		_, error_ := tracker.Read(buffer)
+		if error_ == io.EOF {
+			break
+		}
+	}
+	tracker.finish() // no duplicate final report
+
+	// reports every 250ms (ie: 3 reads), plus the final one
+	if assert.Len(t, recorder.reports, 4) {
+		first := recorder.reports[0]
+		assert.Equal(t, int64(300), first.BytesTransferred)
+		assert.Equal(t, int64(1000), first.TotalBytes)
+		assert.Equal(t, 300*time.Millisecond, first.Elapsed)
+		assert.InDelta(t, 1000, first.Rate, 0.001)
+		assert.Equal(t, 700*time.Millisecond, first.ETA)
+		assert.InDelta(t, 30, first.Percentage(), 0.001)
+		assert.False(t, first.Done)
+
+		final := recorder.last()
+		assert.True(t, final.Done)
+		assert.Equal(t, int64(1000), final.BytesTransferred)
+		assert.Equal(t, time.Duration(0), final.ETA)
+		assert.InDelta(t, 100, final.Percentage(), 0.001)
+	}
+}
+
+func TestProgressReaderUnknownTotal(t *testing.T) {
+	recorder := &progressRecorder{}
+	tracker := newProgressReader(onlyReader{strings.NewReader("abc")}, -5, recorder.record)
+	io.ReadAll(tracker)
+	final := recorder.last()
+	assert.True(t, final.Done)
+	assert.Equal(t, int64(3), final.BytesTransferred)
+	assert.Equal(t, int64(-1), final.TotalBytes)
+	assert.Equal(t, time.Duration(-1), final.ETA)
+	assert.Equal(t, float64(-1), final.Percentage())
+}
+
+func TestFileSubmitProgress(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	var declared_length, body_length int64
+	var file_contents string
+	mockStreamedSendFile(t, &declared_length, &body_length, &file_contents)
+
+	for _, submit := range []func(CsvFile, bool) (*FileValidationResponse, error){BulkValidationSubmit, AiScoringFileSubmit} {
+		recorder := &progressRecorder{}
+		csv_file := CsvFile{
+			File: strings.NewReader(strings.Repeat("valid@example.com\n", 1000)), FileName: "emails.csv",
+			EmailAddressColumn: 1, Progress: recorder.record,
+		}
+		_, error_ := submit(csv_file, false)
+		assert.Nil(t, error_)
+		final := recorder.last()
+		assert.True(t, final.Done)
+		assert.Equal(t, body_length, final.BytesTransferred)
+		assert.Equal(t, declared_length, final.TotalBytes)
+	}
+}
+
+func TestResultFetchProgress(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	contents := strings.Repeat("\"valid@example.com\",\"valid\"\n", 1000)
+	for _, endpoint := range []string{ENDPOINT_FILE_RESULT, ENDPOINT_SCORING_RESULT} {
+		httpmock.RegisterResponder("GET", `=~^(.*)`+endpoint+`(.*)\z`,
+			func(request *http.Request) (*http.Response, error) {
+				response := httpmock.NewStringResponse(200, contents)
+				response.Header.Set("Content-Type", CONTENT_TYPE_OCTET_STREAM)
+				response.ContentLength = int64(len(contents))
+				return response, nil
+			},
+		)
+	}
+
+	fetchers := []func(string, io.Writer, *GetFileOptions) error{BulkValidationResultWithOptions, AiScoringResultWithOptions}
+	for _, fetch := range fetchers {
+		recorder := &progressRecorder{}
+		output := &strings.Builder{}
+		error_ := fetch(testing_file_id, output, &GetFileOptions{Progress: recorder.record})
+		assert.Nil(t, error_)
+		assert.Equal(t, contents, output.String())
+		final := recorder.last()
+		assert.True(t, final.Done)
+		assert.Equal(t, int64(len(contents)), final.BytesTransferred)
+		assert.Equal(t, int64(len(contents)), final.TotalBytes)
+	}
+}
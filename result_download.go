@@ -0,0 +1,316 @@
+package zerobouncego
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrDownloadIntegrity - a downloaded result file does not have the expected
+// size or checksum
+var ErrDownloadIntegrity = errors.New("downloaded file failed its integrity check")
+
+// DOWNLOAD_DEFAULT_MAX_ATTEMPTS - default number of attempts of a result
+// download to a file, each attempt resuming the previous one when possible
+var DOWNLOAD_DEFAULT_MAX_ATTEMPTS = 3
+
+// DOWNLOAD_PART_SUFFIX - suffix of the file a result is downloaded into,
+// before being renamed once complete and verified
+const DOWNLOAD_PART_SUFFIX = ".part"
+
+// DOWNLOAD_VALIDATOR_SUFFIX - suffix, after DOWNLOAD_PART_SUFFIX, of the file
+// keeping the validator (ETag or Last-Modified) of a partial download
+const DOWNLOAD_VALIDATOR_SUFFIX = ".validator"
+
+// DownloadOptions - options of a result download to a file
+type DownloadOptions struct {
+	// GetFileOptions holds the getfile query parameters and progress callback
+	GetFileOptions
+	// ExpectedSize, if set, is the size the downloaded file must have
+	ExpectedSize int64
+	// SHA256, if set, is the hex-encoded checksum the downloaded file must have
+	SHA256 string
+	// MaxAttempts defaults to DOWNLOAD_DEFAULT_MAX_ATTEMPTS
+	MaxAttempts int
+}
+
+// BulkValidationResultToFile - save the results of a bulk validation file at
+// the given path, see GenericResultFetchToFile
+func BulkValidationResultToFile(file_id, path_to_file string, opts *DownloadOptions) error {
+	return GenericResultFetchToFile(file_id, ENDPOINT_FILE_RESULT, path_to_file, opts)
+}
+
+// AiScoringResultToFile - save the results of an AI scoring file at the given
+// path, see GenericResultFetchToFile
+func AiScoringResultToFile(file_id, path_to_file string, opts *DownloadOptions) error {
+	return GenericResultFetchToFile(file_id, ENDPOINT_SCORING_RESULT, path_to_file, opts)
+}
+
+// GenericResultFetchToFile - download a result file into `path_to_file`.
+// Data is first written to `path_to_file` + DOWNLOAD_PART_SUFFIX; when the
+// connection drops, the download is resumed with a Range request (or
+// restarted, if the server does not support them), including by a later
+// call after a crash. Resumes are conditional: the validator (ETag or
+// Last-Modified) of the first response is kept next to the partial file and
+// sent as If-Range, such that the download restarts if the result changed;
+// without validator, the download always restarts. The file is moved to its
+// path once its size matches the one announced by the server; when an
+// expected size or checksum is given and does not match, the partial file is
+// removed and ErrDownloadIntegrity is returned instead. Attempts are not
+// bounded in time, only aborted after TRANSFER_IDLE_TIMEOUT without progress.
+func GenericResultFetchToFile(file_id, endpoint, path_to_file string, opts *DownloadOptions) error {
+	if opts == nil {
+		opts = &DownloadOptions{}
+	}
+	url_to_request, error_ := resultFetchURL(file_id, endpoint, &opts.GetFileOptions, isScoringBulkEndpoint(endpoint))
+	if error_ != nil {
+		return error_
+	}
+	max_attempts := opts.MaxAttempts
+	if max_attempts <= 0 {
+		max_attempts = DOWNLOAD_DEFAULT_MAX_ATTEMPTS
+	}
+
+	part_path := path_to_file + DOWNLOAD_PART_SUFFIX
+	validator_path := part_path + DOWNLOAD_VALIDATOR_SUFFIX
+	for attempt := 1; ; attempt++ {
+		retryable, error_ := downloadAttempt(url_to_request, part_path, validator_path, opts)
+		if error_ == nil {
+			break
+		}
+		if !retryable {
+			if removeEmptyFile(part_path) {
+				os.Remove(validator_path)
+			}
+			return error_
+		}
+		if attempt >= max_attempts {
+			return fmt.Errorf("download failed after %d attempts: %w", attempt, error_)
+		}
+	}
+
+	error_ = verifyDownload(part_path, opts)
+	if error_ != nil {
+		os.Remove(part_path)
+		os.Remove(validator_path)
+		return error_
+	}
+	error_ = os.Rename(part_path, path_to_file)
+	if error_ != nil {
+		return error_
+	}
+	os.Remove(validator_path)
+	return nil
+}
+
+// downloadAttempt - download into the partial file, resuming from its current
+// size if its validator is known; the returned flag tells whether an error is
+// worth another attempt
+func downloadAttempt(url_to_request, part_path, validator_path string, opts *DownloadOptions) (bool, error) {
+	part_file, error_ := os.OpenFile(part_path, os.O_CREATE|os.O_WRONLY, 0644)
+	if error_ != nil {
+		return false, error_
+	}
+	defer part_file.Close()
+	offset, error_ := part_file.Seek(0, io.SeekEnd)
+	if error_ != nil {
+		return false, error_
+	}
+	validator := ""
+	if offset > 0 {
+		stored, error_ := os.ReadFile(validator_path)
+		validator = strings.TrimSpace(string(stored))
+		if error_ != nil || validator == "" {
+			// nothing tells the partial file belongs to the current result
+			offset, error_ = restartPartFile(part_file)
+			if error_ != nil {
+				return false, error_
+			}
+		}
+	}
+
+	request, error_ := http.NewRequest("GET", url_to_request, nil)
+	if error_ != nil {
+		return false, error_
+	}
+	if offset > 0 {
+		request.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		request.Header.Set("If-Range", validator)
+	}
+	response_http, error_ := doTransferRequest(request)
+	if error_ != nil {
+		return true, error_
+	}
+	defer response_http.Body.Close()
+
+	total := int64(-1)
+	switch response_http.StatusCode {
+	case http.StatusPartialContent:
+		start, range_total, ok := parseContentRange(response_http.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			// not the requested range: start over
+			part_file.Truncate(0)
+			return true, fmt.Errorf("unexpected Content-Range %q", response_http.Header.Get("Content-Range"))
+		}
+		if current := responseValidator(response_http.Header); current != "" && current != validator {
+			// the server ignored If-Range
+			part_file.Truncate(0)
+			return true, errors.New("result file changed since the download started")
+		}
+		total = range_total
+	case http.StatusOK:
+		// the server ignored the range, the result changed (If-Range) or
+		// nothing was downloaded yet
+		if offset > 0 {
+			offset, error_ = restartPartFile(part_file)
+			if error_ != nil {
+				return false, error_
+			}
+		}
+		error_ = storeValidator(validator_path, responseValidator(response_http.Header))
+		if error_ != nil {
+			return false, error_
+		}
+		total = response_http.ContentLength
+	case http.StatusRequestedRangeNotSatisfiable:
+		_, range_total, ok := parseContentRange(response_http.Header.Get("Content-Range"))
+		if ok && range_total == offset {
+			return false, nil
+		}
+		part_file.Truncate(0)
+		return true, errors.New("partial download is larger than the result file")
+	default:
+		return response_http.StatusCode >= 500, getFileStatusError(response_http.StatusCode, response_http.Body)
+	}
+
+	var body io.Reader = response_http.Body
+	if opts.Progress != nil {
+		download := newProgressReader(body, total, opts.Progress)
+		download.resume(offset)
+		defer download.finish()
+		body = download
+	}
+	if offset == 0 {
+		error_ = streamGetFileBody(body, response_http.Header.Get("Content-Type"), part_file)
+	} else {
+		error_ = copyGetFileBody(part_file, body)
+	}
+	var read_error *getFileReadError
+	if error_ != nil {
+		return errors.As(error_, &read_error), error_
+	}
+
+	if total >= 0 {
+		size, error_ := part_file.Seek(0, io.SeekCurrent)
+		if error_ != nil {
+			return false, error_
+		}
+		if size != total {
+			return true, fmt.Errorf("download ended at %d bytes of %d", size, total)
+		}
+	}
+	return false, nil
+}
+
+// restartPartFile - empty the partial file, returning the new offset
+func restartPartFile(part_file *os.File) (int64, error) {
+	error_ := part_file.Truncate(0)
+	if error_ != nil {
+		return 0, error_
+	}
+	return part_file.Seek(0, io.SeekStart)
+}
+
+// responseValidator - strong validator of a response, usable in If-Range:
+// its ETag unless weak, else its Last-Modified date ("" if none)
+func responseValidator(header http.Header) string {
+	etag := strings.TrimSpace(header.Get("ETag"))
+	if etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return strings.TrimSpace(header.Get("Last-Modified"))
+}
+
+// storeValidator - keep the validator of a download starting from scratch,
+// removing any previous one when there is none
+func storeValidator(validator_path, validator string) error {
+	if validator == "" {
+		error_ := os.Remove(validator_path)
+		if error_ != nil && !os.IsNotExist(error_) {
+			return error_
+		}
+		return nil
+	}
+	return os.WriteFile(validator_path, []byte(validator), 0644)
+}
+
+// removeEmptyFile - remove a file left empty by a failed download, reporting
+// whether it did
+func removeEmptyFile(path_to_file string) bool {
+	if info, error_ := os.Stat(path_to_file); error_ == nil && info.Size() == 0 {
+		return os.Remove(path_to_file) == nil
+	}
+	return false
+}
+
+// parseContentRange - start and total size of a Content-Range header such as
+// "bytes 100-199/200" or "bytes */200" (total -1 when given as "*")
+func parseContentRange(content_range string) (int64, int64, bool) {
+	content_range = strings.TrimSpace(content_range)
+	if !strings.HasPrefix(content_range, "bytes ") {
+		return 0, 0, false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(content_range, "bytes "), "/", 2)
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	total := int64(-1)
+	if parts[1] != "*" {
+		parsed_total, error_ := strconv.ParseInt(parts[1], 10, 64)
+		if error_ != nil {
+			return 0, 0, false
+		}
+		total = parsed_total
+	}
+	if parts[0] == "*" {
+		return 0, total, true
+	}
+	bounds := strings.SplitN(parts[0], "-", 2)
+	start, error_ := strconv.ParseInt(bounds[0], 10, 64)
+	if error_ != nil || len(bounds) != 2 {
+		return 0, 0, false
+	}
+	return start, total, true
+}
+
+// verifyDownload - check the size and checksum of a completed download
+func verifyDownload(path_to_file string, opts *DownloadOptions) error {
+	if opts.ExpectedSize <= 0 && opts.SHA256 == "" {
+		return nil
+	}
+	file, error_ := os.Open(path_to_file)
+	if error_ != nil {
+		return error_
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, error_ := io.Copy(hash, file)
+	if error_ != nil {
+		return error_
+	}
+	if opts.ExpectedSize > 0 && size != opts.ExpectedSize {
+		return fmt.Errorf("%w: size is %d bytes, expected %d", ErrDownloadIntegrity, size, opts.ExpectedSize)
+	}
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	if opts.SHA256 != "" && !strings.EqualFold(checksum, strings.TrimSpace(opts.SHA256)) {
+		return fmt.Errorf("%w: sha256 is %s, expected %s", ErrDownloadIntegrity, checksum, opts.SHA256)
+	}
+	return nil
+}
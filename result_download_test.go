@@ -0,0 +1,229 @@
+package zerobouncego
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var sample_result_file = strings.Repeat("\"valid@example.com\",\"valid\",\"\"\n", 2000)
+
+// sample_result_etag - ETag of the results served by mockRangeGetFile
+const sample_result_etag = `"result-1"`
+
+// mockRangeGetFile - mock GET/getfile serving `contents` with
+// sample_result_etag, honouring Range headers whose If-Range matches it if
+// `supports_range`; the first `drops` responses fail halfway through. Returns
+// the Range header of each request.
+func mockRangeGetFile(contents string, supports_range bool, drops int) *[]string {
+	ranges := &[]string{}
+	httpmock.RegisterResponder("GET", `=~^(.*)`+ENDPOINT_FILE_RESULT+`(.*)\z`,
+		func(request *http.Request) (*http.Response, error) {
+			range_header := request.Header.Get("Range")
+			*ranges = append(*ranges, range_header)
+			status, body := 200, contents
+			headers := http.Header{"Content-Type": {CONTENT_TYPE_OCTET_STREAM}, "Etag": {sample_result_etag}}
+			if range_header != "" && supports_range && request.Header.Get("If-Range") == sample_result_etag {
+				var start int
+				fmt.Sscanf(range_header, "bytes=%d-", &start)
+				if start >= len(contents) {
+					response := httpmock.NewStringResponse(416, "")
+					response.Header.Set("Content-Range", fmt.Sprintf("bytes */%d", len(contents)))
+					return response, nil
+				}
+				status, body = 206, contents[start:]
+				headers.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(contents)-1, len(contents)))
+			}
+
+			var body_reader io.Reader = strings.NewReader(body)
+			if len(*ranges) <= drops {
+				body_reader = io.MultiReader(strings.NewReader(body[:len(body)/2]), &failingReader{strings.NewReader("")})
+			}
+			return &http.Response{
+				StatusCode:    status,
+				Header:        headers,
+				Body:          io.NopCloser(body_reader),
+				ContentLength: int64(len(body)),
+			}, nil
+		},
+	)
+	return ranges
+}
+
+func TestResultToFileResumes(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	ranges := mockRangeGetFile(sample_result_file, true, 1)
+
+	path_to_file := filepath.Join(t.TempDir(), "results.csv")
+	recorder := &progressRecorder{}
+	checksum := sha256.Sum256([]byte(sample_result_file))
+	error_ := BulkValidationResultToFile(testing_file_id, path_to_file, &DownloadOptions{
+		GetFileOptions: GetFileOptions{Progress: recorder.record},
+		ExpectedSize:   int64(len(sample_result_file)),
+		SHA256:         hex.EncodeToString(checksum[:]),
+	})
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	contents, _ := os.ReadFile(path_to_file)
+	assert.Equal(t, sample_result_file, string(contents))
+	assert.Equal(t, []string{"", fmt.Sprintf("bytes=%d-", len(sample_result_file)/2)}, *ranges)
+	assert.NoFileExists(t, path_to_file+DOWNLOAD_PART_SUFFIX)
+	assert.NoFileExists(t, path_to_file+DOWNLOAD_PART_SUFFIX+DOWNLOAD_VALIDATOR_SUFFIX)
+
+	final := recorder.last()
+	assert.True(t, final.Done)
+	assert.Equal(t, int64(len(sample_result_file)), final.BytesTransferred)
+	assert.Equal(t, int64(len(sample_result_file)), final.TotalBytes)
+}
+
+func TestResultToFileRestartsWithoutRangeSupport(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	ranges := mockRangeGetFile(sample_result_file, false, 2)
+
+	path_to_file := filepath.Join(t.TempDir(), "results.csv")
+	error_ := BulkValidationResultToFile(testing_file_id, path_to_file, nil)
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	contents, _ := os.ReadFile(path_to_file)
+	assert.Equal(t, sample_result_file, string(contents))
+	assert.Len(t, *ranges, 3)
+}
+
+func TestResultToFileResumesPreviousRun(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	ranges := mockRangeGetFile(sample_result_file, true, 0)
+
+	path_to_file := filepath.Join(t.TempDir(), "results.csv")
+	part_path := path_to_file + DOWNLOAD_PART_SUFFIX
+	os.WriteFile(part_path, []byte(sample_result_file[:100]), 0644)
+	os.WriteFile(part_path+DOWNLOAD_VALIDATOR_SUFFIX, []byte(sample_result_etag), 0644)
+	assert.Nil(t, BulkValidationResultToFile(testing_file_id, path_to_file, nil))
+	contents, _ := os.ReadFile(path_to_file)
+	assert.Equal(t, sample_result_file, string(contents))
+	assert.Equal(t, []string{"bytes=100-"}, *ranges)
+
+	// an already complete partial file is only renamed
+	*ranges = nil
+	os.WriteFile(part_path, []byte(sample_result_file), 0644)
+	os.WriteFile(part_path+DOWNLOAD_VALIDATOR_SUFFIX, []byte(sample_result_etag), 0644)
+	assert.Nil(t, BulkValidationResultToFile(testing_file_id, path_to_file, nil))
+	assert.Equal(t, []string{fmt.Sprintf("bytes=%d-", len(sample_result_file))}, *ranges)
+	assert.NoFileExists(t, path_to_file+DOWNLOAD_PART_SUFFIX)
+}
+
+func TestResultToFileRestartsChangedResult(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	ranges := mockRangeGetFile(sample_result_file, true, 0)
+
+	// partial file of a previous version of the result: If-Range does not match
+	path_to_file := filepath.Join(t.TempDir(), "results.csv")
+	part_path := path_to_file + DOWNLOAD_PART_SUFFIX
+	os.WriteFile(part_path, []byte("stale contents"), 0644)
+	os.WriteFile(part_path+DOWNLOAD_VALIDATOR_SUFFIX, []byte(`"result-0"`), 0644)
+	assert.Nil(t, BulkValidationResultToFile(testing_file_id, path_to_file, nil))
+	contents, _ := os.ReadFile(path_to_file)
+	assert.Equal(t, sample_result_file, string(contents))
+	assert.Equal(t, []string{"bytes=14-"}, *ranges)
+	assert.NoFileExists(t, part_path+DOWNLOAD_VALIDATOR_SUFFIX)
+
+	// without validator, a partial file is not resumed
+	*ranges = nil
+	os.WriteFile(part_path, []byte("stale contents"), 0644)
+	assert.Nil(t, BulkValidationResultToFile(testing_file_id, path_to_file, nil))
+	contents, _ = os.ReadFile(path_to_file)
+	assert.Equal(t, sample_result_file, string(contents))
+	assert.Equal(t, []string{""}, *ranges)
+}
+
+func TestResponseValidator(t *testing.T) {
+	assert.Equal(t, `"abc"`, responseValidator(http.Header{"Etag": {`"abc"`}, "Last-Modified": {"Mon, 02 Jan 2023 15:04:05 GMT"}}))
+	assert.Equal(t, "Mon, 02 Jan 2023 15:04:05 GMT", responseValidator(http.Header{"Etag": {`W/"abc"`}, "Last-Modified": {"Mon, 02 Jan 2023 15:04:05 GMT"}}))
+	assert.Equal(t, "", responseValidator(http.Header{}))
+}
+
+func TestResultToFileIntegrityFailure(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	mockRangeGetFile(sample_result_file, true, 0)
+
+	path_to_file := filepath.Join(t.TempDir(), "results.csv")
+	error_ := BulkValidationResultToFile(testing_file_id, path_to_file, &DownloadOptions{SHA256: strings.Repeat("0", 64)})
+	assert.True(t, errors.Is(error_, ErrDownloadIntegrity))
+	assert.NoFileExists(t, path_to_file)
+	assert.NoFileExists(t, path_to_file+DOWNLOAD_PART_SUFFIX)
+
+	error_ = BulkValidationResultToFile(testing_file_id, path_to_file, &DownloadOptions{ExpectedSize: 10})
+	assert.True(t, errors.Is(error_, ErrDownloadIntegrity))
+}
+
+func TestResultToFileGivesUp(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	ranges := mockRangeGetFile(sample_result_file, true, 10)
+
+	path_to_file := filepath.Join(t.TempDir(), "results.csv")
+	error_ := BulkValidationResultToFile(testing_file_id, path_to_file, &DownloadOptions{MaxAttempts: 2})
+	if assert.NotNil(t, error_) {
+		assert.Contains(t, error_.Error(), "download failed after 2 attempts")
+	}
+	assert.Len(t, *ranges, 2)
+	assert.NoFileExists(t, path_to_file)
+}
+
+func TestResultToFileErrorPayload(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	mockOkResponse("GET", ENDPOINT_SCORING_RESULT, sample_validation_result_200_not_success)
+
+	path_to_file := filepath.Join(t.TempDir(), "results.csv")
+	error_ := AiScoringResultToFile(testing_file_id, path_to_file, nil)
+	if assert.NotNil(t, error_) {
+		assert.Equal(t, "File cannot be found.", error_.Error())
+	}
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+	assert.NoFileExists(t, path_to_file)
+	assert.NoFileExists(t, path_to_file+DOWNLOAD_PART_SUFFIX)
+}
+
+func TestParseContentRange(t *testing.T) {
+	start, total, ok := parseContentRange("bytes 100-199/200")
+	assert.True(t, ok)
+	assert.Equal(t, int64(100), start)
+	assert.Equal(t, int64(200), total)
+
+	_, total, ok = parseContentRange("bytes */300")
+	assert.True(t, ok)
+	assert.Equal(t, int64(300), total)
+
+	_, total, ok = parseContentRange("bytes 0-9/*")
+	assert.True(t, ok)
+	assert.Equal(t, int64(-1), total)
+
+	for _, invalid := range []string{"", "items 0-1/2", "bytes 0-1", "bytes x-1/2"} {
+		_, _, ok = parseContentRange(invalid)
+		assert.Falsef(t, ok, "%q should not parse", invalid)
+	}
+}
@@ -0,0 +1,256 @@
+package zerobouncego
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+
+	"gopkg.in/guregu/null.v4"
+)
+
+// ResultParseOptions - how a bulk result file is parsed
+type ResultParseOptions struct {
+	// EmailAddressColumn is the 1-based column holding the addresses, as in
+	// the submitted file (defaults to 1)
+	EmailAddressColumn int
+	// DownloadType is the getfile download type the results were fetched
+	// with (see GetFileOptions), nil meaning the default one
+	DownloadType *string
+}
+
+// BulkValidationRow - one row of a bulk validation result
+type BulkValidationRow struct {
+	// ValidateResponse holds the result of the row; for phase 2 downloads,
+	// the phase 2 result
+	ValidateResponse
+	// Phase is the phase the result comes from: 1 or 2, or 0 when unknown
+	// (eg: combined downloads without separate phase 2 columns)
+	Phase int
+	// Phase2 is the phase 2 result of a file having both phase 1 and phase 2
+	// result columns (nil otherwise)
+	Phase2 *ValidateResponse
+	// Input holds the original columns of the row, in the order of
+	// ValidationResultReader.InputHeader
+	Input []string
+	// Extra holds the result columns without ValidateResponse field (eg:
+	// activity data), by header
+	Extra map[string]string
+	// Row is the 1-based position of the row, the header row excluded
+	Row int
+
+	record []string
+}
+
+// Final - the phase 2 result of the row when it has one, its result otherwise
+func (b BulkValidationRow) Final() ValidateResponse {
+	if b.Phase2 != nil && b.Phase2.Status != "" {
+		return *b.Phase2
+	}
+	return b.ValidateResponse
+}
+
+// validationResultColumns - bulk validation result headers (normalized, as
+// in "zb status") and the fields they fill
+var validationResultColumns = map[string]func(response *ValidateResponse, value string){
+	"zb status":          func(r *ValidateResponse, v string) { r.Status = strings.ToLower(strings.TrimSpace(v)) },
+	"zb sub status":      func(r *ValidateResponse, v string) { r.SubStatus = strings.ToLower(strings.TrimSpace(v)) },
+	"zb account":         func(r *ValidateResponse, v string) { r.Account = v },
+	"zb domain":          func(r *ValidateResponse, v string) { r.Domain = v },
+	"zb first name":      func(r *ValidateResponse, v string) { r.Firstname = nullString(v) },
+	"zb last name":       func(r *ValidateResponse, v string) { r.Lastname = nullString(v) },
+	"zb gender":          func(r *ValidateResponse, v string) { r.Gender = nullString(v) },
+	"zb free email":      func(r *ValidateResponse, v string) { r.FreeEmail = strings.EqualFold(strings.TrimSpace(v), "true") },
+	"zb mx found":        func(r *ValidateResponse, v string) { r.MxFound = v },
+	"zb mx record":       func(r *ValidateResponse, v string) { r.MxRecord = v },
+	"zb smtp provider":   func(r *ValidateResponse, v string) { r.SMTPProvider = nullString(v) },
+	"zb did you mean":    func(r *ValidateResponse, v string) { r.DidYouMean = nullString(v) },
+	"zb domain age days": func(r *ValidateResponse, v string) { r.DomainAgeDays = nullString(v) },
+	"zb country":         func(r *ValidateResponse, v string) { r.Country = nullString(v) },
+	"zb region":          func(r *ValidateResponse, v string) { r.Region = nullString(v) },
+	"zb city":            func(r *ValidateResponse, v string) { r.City = nullString(v) },
+	"zb zipcode":         func(r *ValidateResponse, v string) { r.Zipcode = nullString(v) },
+	"zb processed at":    func(r *ValidateResponse, v string) { r.RawProcessedAt = v },
+}
+
+// nullString - null.String from a csv cell, empty cells being null
+func nullString(value string) null.String {
+	return null.NewString(value, value != "")
+}
+
+// resultPhasePattern - phase marker of a result header, as in "ZB Status
+// (Phase 2)" or "phase_2_zb_status"
+var resultPhasePattern = regexp.MustCompile(`\(?\bphase ?([12])\b\)?`)
+
+// resultHeaderKey - normalized form of a result header (lowercased, with
+// single spaces instead of underscores) and the phase it is marked with
+func resultHeaderKey(header string) (string, int) {
+	key := strings.ToLower(strings.TrimPrefix(header, "\ufeff"))
+	key = strings.Join(strings.Fields(strings.ReplaceAll(key, "_", " ")), " ")
+	phase := 0
+	if match := resultPhasePattern.FindStringSubmatch(key); match != nil {
+		phase = int(match[1][0] - '0')
+		key = strings.Join(strings.Fields(resultPhasePattern.ReplaceAllString(key, " ")), " ")
+	}
+	return key, phase
+}
+
+// validationResultColumn - what a column of a result file holds
+type validationResultColumn struct {
+	header string
+	setter func(response *ValidateResponse, value string)
+	phase2 bool // fills BulkValidationRow.Phase2
+	input  bool // original column
+}
+
+// ValidationResultReader - reads the rows of a bulk validation result file
+type ValidationResultReader struct {
+	csv_reader   *csv.Reader
+	columns      []validationResultColumn
+	header       []string
+	input_header []string
+	email_index  int
+	phase        int
+	split_phases bool
+	row          int
+}
+
+// NewValidationResultReader - read the header of a bulk validation result
+// file, which must have a "ZB Status" column. The columns before the result
+// ones are the original columns of the submitted file; result columns are
+// recognized whatever their case and separators, and may be marked with
+// their phase, as in "ZB Status (Phase 2)". Returns io.EOF for empty files.
+func NewValidationResultReader(reader io.Reader, options *ResultParseOptions) (*ValidationResultReader, error) {
+	if options == nil {
+		options = &ResultParseOptions{}
+	}
+	csv_reader := csv.NewReader(reader)
+	csv_reader.FieldsPerRecord = -1
+	csv_reader.LazyQuotes = true
+	header, error_ := csv_reader.Read()
+	if error_ == io.EOF {
+		return nil, io.EOF
+	}
+	if error_ != nil {
+		return nil, errors.New("error reading validation results: " + error_.Error())
+	}
+
+	result_reader := &ValidationResultReader{csv_reader: csv_reader, email_index: options.EmailAddressColumn - 1}
+	if result_reader.email_index < 0 {
+		result_reader.email_index = 0
+	}
+	if options.DownloadType != nil {
+		switch *options.DownloadType {
+		case DownloadTypePhase1:
+			result_reader.phase = 1
+		case DownloadTypePhase2:
+			result_reader.phase = 2
+		}
+	}
+
+	main_status, phase_2_status := false, false
+	for _, name := range header {
+		key, phase := resultHeaderKey(name)
+		column := validationResultColumn{header: strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))}
+		column.setter = validationResultColumns[key]
+		column.phase2 = phase == 2
+		column.input = column.setter == nil && phase == 0 && !strings.HasPrefix(key, "zb ")
+		if key == "zb status" {
+			main_status = main_status || !column.phase2
+			phase_2_status = phase_2_status || column.phase2
+		}
+		result_reader.header = append(result_reader.header, column.header)
+		if column.input {
+			result_reader.input_header = append(result_reader.input_header, column.header)
+		}
+		result_reader.columns = append(result_reader.columns, column)
+	}
+	if !main_status && !phase_2_status {
+		return nil, errors.New("validation results have no ZB Status column")
+	}
+	if main_status && phase_2_status {
+		result_reader.split_phases = true
+		result_reader.phase = 1
+	} else if phase_2_status {
+		result_reader.phase = 2
+	}
+	return result_reader, nil
+}
+
+// Header - headers of every column of the file
+func (v *ValidationResultReader) Header() []string {
+	return v.header
+}
+
+// InputHeader - headers of the original columns of the submitted file
+func (v *ValidationResultReader) InputHeader() []string {
+	return v.input_header
+}
+
+// Read - next row of the file, io.EOF once they were all read; empty lines
+// are skipped
+func (v *ValidationResultReader) Read() (*BulkValidationRow, error) {
+	record, error_ := v.csv_reader.Read()
+	for error_ == nil && len(record) == 0 {
+		record, error_ = v.csv_reader.Read()
+	}
+	if error_ == io.EOF {
+		return nil, io.EOF
+	}
+	if error_ != nil {
+		return nil, errors.New("error reading validation results: " + error_.Error())
+	}
+	v.row++
+
+	row := &BulkValidationRow{Phase: v.phase, Row: v.row, record: record}
+	if v.email_index < len(record) {
+		row.Address = strings.TrimSpace(record[v.email_index])
+	}
+	if v.split_phases {
+		row.Phase2 = &ValidateResponse{Address: row.Address}
+	}
+	for index, column := range v.columns {
+		value := ""
+		if index < len(record) {
+			value = record[index]
+		}
+		switch {
+		case column.input:
+			row.Input = append(row.Input, value)
+		case column.setter == nil:
+			if row.Extra == nil {
+				row.Extra = make(map[string]string)
+			}
+			row.Extra[column.header] = value
+		case column.phase2 && v.split_phases:
+			column.setter(row.Phase2, value)
+		default:
+			column.setter(&row.ValidateResponse, value)
+		}
+	}
+	return row, nil
+}
+
+// ParseValidationResults - read every row of a bulk validation result file,
+// see NewValidationResultReader
+func ParseValidationResults(reader io.Reader, options *ResultParseOptions) ([]BulkValidationRow, error) {
+	result_reader, error_ := NewValidationResultReader(reader, options)
+	if error_ == io.EOF {
+		return nil, nil
+	}
+	if error_ != nil {
+		return nil, error_
+	}
+	var rows []BulkValidationRow
+	for {
+		row, error_ := result_reader.Read()
+		if error_ == io.EOF {
+			return rows, nil
+		}
+		if error_ != nil {
+			return rows, error_
+		}
+		rows = append(rows, *row)
+	}
+}
@@ -0,0 +1,126 @@
+package zerobouncego
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const sample_phased_validation_result = "\"Name\",\"Email\",\"ZB Status\",\"ZB Sub Status\",\"ZB Status (Phase 2)\",\"ZB Sub Status (Phase 2)\",\"ZB Last Known Activity\"\n" +
+	"\"Jane\",\"jane@example.com\",\"Catch-All\",\"\",\"valid\",\"\",\"30 days\"\n" +
+	"\"John\",\"john@example.com\",\"invalid\",\"Mailbox_Not_Found\",\"\",\"\",\"\"\n"
+
+func TestResultHeaderKey(t *testing.T) {
+	for header, expected := range map[string]struct {
+		key   string
+		phase int
+	}{
+		"ZB Status":               {"zb status", 0},
+		"\ufeffzb_sub_status":     {"zb sub status", 0},
+		"ZB  Free Email ":         {"zb free email", 0},
+		"ZB Status (Phase 2)":     {"zb status", 2},
+		"phase_1_zb_status":       {"zb status", 1},
+		"ZB Did You Mean Phase2":  {"zb did you mean", 2},
+		"Email Address":           {"email address", 0},
+		"Emphase 2 is not marked": {"emphase 2 is not marked", 0},
+	} {
+		key, phase := resultHeaderKey(header)
+		assert.Equal(t, expected.key, key, header)
+		assert.Equal(t, expected.phase, phase, header)
+	}
+}
+
+func TestParseValidationResults(t *testing.T) {
+	rows, error_ := ParseValidationResults(strings.NewReader(sample_list_validation_result), nil)
+	assert.Nil(t, error_)
+	if assert.Len(t, rows, 2) {
+		assert.Equal(t, "USER@GMAL.COM", rows[1].Address)
+		assert.Equal(t, ValidateStatusInvalid, rows[1].Status)
+		assert.Equal(t, ValidateSubStatusPossibleTypo, rows[1].SubStatus)
+		assert.Equal(t, []string{"USER@GMAL.COM"}, rows[1].Input)
+		assert.Equal(t, 2, rows[1].Row)
+		assert.Equal(t, 0, rows[1].Phase)
+		assert.Nil(t, rows[1].Phase2)
+		assert.Nil(t, rows[1].Extra)
+	}
+
+	_, error_ = ParseValidationResults(strings.NewReader("email,status\na@b.c,valid\n"), nil)
+	assert.NotNil(t, error_)
+
+	rows, error_ = ParseValidationResults(strings.NewReader(""), nil)
+	assert.Nil(t, error_)
+	assert.Empty(t, rows)
+
+	phase_1 := DownloadTypePhase1
+	rows, error_ = ParseValidationResults(strings.NewReader(sample_list_validation_result), &ResultParseOptions{DownloadType: &phase_1})
+	assert.Nil(t, error_)
+	assert.Equal(t, 1, rows[0].Phase)
+}
+
+func TestValidationResultReaderKeepsColumns(t *testing.T) {
+	contents := "First Name,Email,Company,zb_status,zb_sub_status,zb_free_email,ZB Activity Data Count\n" +
+		"Jane,jane@example.com,ACME,Valid,,TRUE,3\n" +
+		"\n" +
+		"John,john@example.com,\"Foo, Inc\",do_not_mail,Role_Based\n"
+	phase_2 := DownloadTypePhase2
+	reader, error_ := NewValidationResultReader(strings.NewReader(contents), &ResultParseOptions{EmailAddressColumn: 2, DownloadType: &phase_2})
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{"First Name", "Email", "Company", "zb_status", "zb_sub_status", "zb_free_email", "ZB Activity Data Count"}, reader.Header())
+	assert.Equal(t, []string{"First Name", "Email", "Company"}, reader.InputHeader())
+
+	row, error_ := reader.Read()
+	assert.Nil(t, error_)
+	assert.Equal(t, "jane@example.com", row.Address)
+	assert.Equal(t, ValidateStatusValid, row.Status)
+	assert.True(t, row.FreeEmail)
+	assert.Equal(t, []string{"Jane", "jane@example.com", "ACME"}, row.Input)
+	assert.Equal(t, map[string]string{"ZB Activity Data Count": "3"}, row.Extra)
+	assert.Equal(t, 2, row.Phase)
+
+	row, error_ = reader.Read()
+	assert.Nil(t, error_)
+	assert.Equal(t, 2, row.Row)
+	assert.Equal(t, ValidateStatusDoNotMail, row.Status)
+	assert.Equal(t, ValidateSubStatusRoleBased, row.SubStatus)
+	assert.Equal(t, []string{"John", "john@example.com", "Foo, Inc"}, row.Input)
+	assert.Equal(t, "", row.Extra["ZB Activity Data Count"])
+
+	_, error_ = reader.Read()
+	assert.Equal(t, io.EOF, error_)
+
+	_, error_ = NewValidationResultReader(strings.NewReader(""), nil)
+	assert.Equal(t, io.EOF, error_)
+}
+
+func TestValidationResultPhases(t *testing.T) {
+	combined := DownloadTypeCombined
+	rows, error_ := ParseValidationResults(strings.NewReader(sample_phased_validation_result), &ResultParseOptions{EmailAddressColumn: 2, DownloadType: &combined})
+	if !assert.Nil(t, error_) || !assert.Len(t, rows, 2) {
+		t.FailNow()
+	}
+	assert.Equal(t, 1, rows[0].Phase)
+	assert.Equal(t, ValidateStatusCatchAll, rows[0].Status)
+	if assert.NotNil(t, rows[0].Phase2) {
+		assert.Equal(t, "jane@example.com", rows[0].Phase2.Address)
+		assert.Equal(t, ValidateStatusValid, rows[0].Phase2.Status)
+	}
+	assert.Equal(t, ValidateStatusValid, rows[0].Final().Status)
+	assert.Equal(t, "30 days", rows[0].Extra["ZB Last Known Activity"])
+	assert.Equal(t, []string{"Jane", "jane@example.com"}, rows[0].Input)
+	// no phase 2 result
+	assert.Equal(t, ValidateStatusInvalid, rows[1].Final().Status)
+	assert.Equal(t, ValidateSubStatusMailboxNotFound, rows[1].Final().SubStatus)
+
+	// phase 2 columns only
+	rows, error_ = ParseValidationResults(strings.NewReader("email,ZB Status (Phase 2)\na@example.com,valid\n"), nil)
+	assert.Nil(t, error_)
+	if assert.Len(t, rows, 1) {
+		assert.Equal(t, 2, rows[0].Phase)
+		assert.Equal(t, ValidateStatusValid, rows[0].Status)
+		assert.Nil(t, rows[0].Phase2)
+	}
+}
@@ -0,0 +1,317 @@
+package zerobouncego
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// SCORING_SCORE_HEADER - header of the score column added by the API
+const SCORING_SCORE_HEADER = "ZeroBounceQualityScore"
+
+// SCORE_BAND_NONE - band of the rows without score, or scoring below every band
+const SCORE_BAND_NONE = "none"
+
+// AiScoringRow - one row of an AI scoring result
+type AiScoringRow struct {
+	Email string
+	Score float64
+	// Scored is false when the score cell of the row is empty
+	Scored bool
+	// Input holds the original columns of the row, in the order of
+	// ScoringResultReader.InputHeader
+	Input []string
+	// Row is the 1-based position of the row, the header row excluded
+	Row int
+
+	record []string
+}
+
+// ScoringResultReader - reads the rows of an AI scoring result file
+type ScoringResultReader struct {
+	csv_reader   *csv.Reader
+	header       []string
+	input_header []string
+	email_index  int
+	score_index  int
+	row          int
+}
+
+// NewScoringResultReader - read the header of an AI scoring result file,
+// whose score column is SCORING_SCORE_HEADER (or, failing that, the last one
+// named after the score, the API appending it after the submitted columns),
+// the others being the original columns of the submitted file. Returns io.EOF
+// for empty files.
+func NewScoringResultReader(reader io.Reader, options *ResultParseOptions) (*ScoringResultReader, error) {
+	if options == nil {
+		options = &ResultParseOptions{}
+	}
+	csv_reader := csv.NewReader(reader)
+	csv_reader.FieldsPerRecord = -1
+	csv_reader.LazyQuotes = true
+	header, error_ := csv_reader.Read()
+	if error_ == io.EOF {
+		return nil, io.EOF
+	}
+	if error_ != nil {
+		return nil, errors.New("error reading scoring results: " + error_.Error())
+	}
+
+	scoring_reader := &ScoringResultReader{csv_reader: csv_reader, email_index: options.EmailAddressColumn - 1, score_index: -1}
+	if scoring_reader.email_index < 0 {
+		scoring_reader.email_index = 0
+	}
+	exact_index, last_index := -1, -1
+	for index, name := range header {
+		name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		scoring_reader.header = append(scoring_reader.header, name)
+		if exact_index < 0 && strings.EqualFold(name, SCORING_SCORE_HEADER) {
+			exact_index = index
+		}
+		if strings.Contains(strings.ToLower(name), "score") {
+			last_index = index
+		}
+	}
+	scoring_reader.score_index = exact_index
+	if scoring_reader.score_index < 0 {
+		scoring_reader.score_index = last_index
+	}
+	if scoring_reader.score_index < 0 {
+		return nil, errors.New("scoring results have no score column")
+	}
+	for index, name := range scoring_reader.header {
+		if index != scoring_reader.score_index {
+			scoring_reader.input_header = append(scoring_reader.input_header, name)
+		}
+	}
+	return scoring_reader, nil
+}
+
+// Header - headers of every column of the file
+func (s *ScoringResultReader) Header() []string {
+	return s.header
+}
+
+// InputHeader - headers of the original columns of the submitted file
+func (s *ScoringResultReader) InputHeader() []string {
+	return s.input_header
+}
+
+// Read - next row of the file, io.EOF once they were all read
+func (s *ScoringResultReader) Read() (*AiScoringRow, error) {
+	record, error_ := s.csv_reader.Read()
+	if error_ == io.EOF {
+		return nil, io.EOF
+	}
+	if error_ != nil {
+		return nil, errors.New("error reading scoring results: " + error_.Error())
+	}
+	s.row++
+
+	row := &AiScoringRow{Row: s.row, record: record}
+	if s.email_index < len(record) {
+		row.Email = strings.TrimSpace(record[s.email_index])
+	}
+	for index, value := range record {
+		if index != s.score_index {
+			row.Input = append(row.Input, value)
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		row.Score, error_ = strconv.ParseFloat(value, 64)
+		if error_ != nil {
+			return row, fmt.Errorf("invalid score %q for %s (row %d)", value, row.Email, row.Row)
+		}
+		row.Scored = true
+	}
+	return row, nil
+}
+
+// ParseScoringResults - read every row of an AI scoring result file, see
+// NewScoringResultReader
+func ParseScoringResults(reader io.Reader, options *ResultParseOptions) ([]AiScoringRow, error) {
+	scoring_reader, error_ := NewScoringResultReader(reader, options)
+	if error_ == io.EOF {
+		return nil, nil
+	}
+	if error_ != nil {
+		return nil, error_
+	}
+	var rows []AiScoringRow
+	for {
+		row, error_ := scoring_reader.Read()
+		if error_ == io.EOF {
+			return rows, nil
+		}
+		if error_ != nil {
+			return rows, error_
+		}
+		rows = append(rows, *row)
+	}
+}
+
+// ScoreBand - named range of scores, from Min (included) up to the Min of
+// the next band
+type ScoreBand struct {
+	Name string
+	Min  float64
+}
+
+// ScoreBands - set of score bands, in any order
+type ScoreBands []ScoreBand
+
+// DEFAULT_SCORE_BANDS - bands used when none are given
+var DEFAULT_SCORE_BANDS = ScoreBands{{Name: "low", Min: 0}, {Name: "medium", Min: 4}, {Name: "high", Min: 8}}
+
+// Band - name of the band a score falls in: the one with the highest Min not
+// above it (SCORE_BAND_NONE when below every band)
+func (s ScoreBands) Band(score float64) string {
+	band := SCORE_BAND_NONE
+	best := 0.0
+	for _, candidate := range s {
+		if candidate.Min <= score && (band == SCORE_BAND_NONE || candidate.Min > best) {
+			band, best = candidate.Name, candidate.Min
+		}
+	}
+	return band
+}
+
+// RowBand - band of a scoring row (SCORE_BAND_NONE for rows without score)
+func (s ScoreBands) RowBand(row AiScoringRow) string {
+	if !row.Scored {
+		return SCORE_BAND_NONE
+	}
+	return s.Band(row.Score)
+}
+
+// BucketScoringRows - group rows by band (DEFAULT_SCORE_BANDS if none are
+// given), keeping their order
+func BucketScoringRows(rows []AiScoringRow, bands ScoreBands) map[string][]AiScoringRow {
+	if len(bands) == 0 {
+		bands = DEFAULT_SCORE_BANDS
+	}
+	buckets := make(map[string][]AiScoringRow)
+	for _, row := range rows {
+		band := bands.RowBand(row)
+		buckets[band] = append(buckets[band], row)
+	}
+	return buckets
+}
+
+// WriteScoreBands - copy the rows of an AI scoring result file into one csv
+// output per band (DEFAULT_SCORE_BANDS if none are given), each starting
+// with the header of the file. Outputs are opened on their first row, and
+// closed at the end when they implement io.Closer. Returns the number of
+// rows written by band.
+func WriteScoreBands(reader io.Reader, bands ScoreBands, options *ResultParseOptions, open func(band string) (io.Writer, error)) (map[string]int, error) {
+	if len(bands) == 0 {
+		bands = DEFAULT_SCORE_BANDS
+	}
+	scoring_reader, error_ := NewScoringResultReader(reader, options)
+	if error_ == io.EOF {
+		return map[string]int{}, nil
+	}
+	if error_ != nil {
+		return nil, error_
+	}
+	splitter := newCsvSplitter(scoring_reader.Header(), open)
+	for {
+		row, error_ := scoring_reader.Read()
+		if error_ == io.EOF {
+			return splitter.counts, splitter.close()
+		}
+		if error_ == nil {
+			error_ = splitter.write(bands.RowBand(*row), row.record)
+		}
+		if error_ != nil {
+			splitter.close()
+			return splitter.counts, error_
+		}
+	}
+}
+
+// WriteScoreBandFiles - WriteScoreBands into files, named after the given
+// pattern in which "%s" stands for the band name (eg: "scores_%s.csv")
+func WriteScoreBandFiles(reader io.Reader, bands ScoreBands, options *ResultParseOptions, path_pattern string) (map[string]int, error) {
+	open, error_ := patternFileOpener(path_pattern)
+	if error_ != nil {
+		return nil, error_
+	}
+	return WriteScoreBands(reader, bands, options, open)
+}
+
+// patternFileOpener - opener of csvSplitter outputs creating files named
+// after a pattern, in which "%s" stands for the bucket name
+func patternFileOpener(path_pattern string) (func(bucket string) (io.Writer, error), error) {
+	if !strings.Contains(path_pattern, "%s") {
+		return nil, errors.New(`the path pattern has no "%s" for the bucket name`)
+	}
+	return func(bucket string) (io.Writer, error) {
+		return os.Create(strings.ReplaceAll(path_pattern, "%s", bucket))
+	}, nil
+}
+
+// csvSplitter - writes csv records into one output per bucket, each output
+// being opened on its first record and starting with the header
+type csvSplitter struct {
+	header  []string
+	open    func(bucket string) (io.Writer, error)
+	writers map[string]*csv.Writer
+	outputs []io.Writer
+	counts  map[string]int
+}
+
+func newCsvSplitter(header []string, open func(bucket string) (io.Writer, error)) *csvSplitter {
+	return &csvSplitter{header: header, open: open, writers: make(map[string]*csv.Writer), counts: make(map[string]int)}
+}
+
+// write - add a record to the output of a bucket
+func (c *csvSplitter) write(bucket string, record []string) error {
+	writer, ok := c.writers[bucket]
+	if !ok {
+		output, error_ := c.open(bucket)
+		if error_ != nil {
+			return fmt.Errorf("could not open the output of %q: %w", bucket, error_)
+		}
+		c.outputs = append(c.outputs, output)
+		writer = csv.NewWriter(output)
+		c.writers[bucket] = writer
+		if error_ = writer.Write(c.header); error_ != nil {
+			return error_
+		}
+	}
+	error_ := writer.Write(record)
+	if error_ == nil {
+		c.counts[bucket]++
+	}
+	return error_
+}
+
+// close - flush every output, and close those that can be; returns the
+// first error met
+func (c *csvSplitter) close() error {
+	var first_error error
+	for _, writer := range c.writers {
+		writer.Flush()
+		if error_ := writer.Error(); error_ != nil && first_error == nil {
+			first_error = error_
+		}
+	}
+	for _, output := range c.outputs {
+		if closer, ok := output.(io.Closer); ok {
+			if error_ := closer.Close(); error_ != nil && first_error == nil {
+				first_error = error_
+			}
+		}
+	}
+	c.writers = map[string]*csv.Writer{}
+	c.outputs = nil
+	return first_error
+}
@@ -0,0 +1,157 @@
+package zerobouncego
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const sample_banded_scoring_result = "Name,Email,ZeroBounceQualityScore\n" +
+	"Jane,jane@example.com,9\n" +
+	"John,john@example.com,2\n" +
+	"Jim,jim@example.com,\n" +
+	"Joe,joe@example.com,8\n"
+
+// bufferCloser - buffer recording whether it was closed
+type bufferCloser struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (b *bufferCloser) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestParseScoringResults(t *testing.T) {
+	rows, error_ := ParseScoringResults(strings.NewReader(sample_scoring_result), nil)
+	assert.Nil(t, error_)
+	if assert.Len(t, rows, 2) {
+		assert.Equal(t, "unknown@example.com", rows[1].Email)
+		assert.Equal(t, 3.5, rows[1].Score)
+		assert.True(t, rows[1].Scored)
+		assert.Equal(t, []string{"unknown@example.com"}, rows[1].Input)
+		assert.Equal(t, 2, rows[1].Row)
+	}
+
+	rows, error_ = ParseScoringResults(strings.NewReader(""), nil)
+	assert.Nil(t, error_)
+	assert.Nil(t, rows)
+
+	_, error_ = ParseScoringResults(strings.NewReader("email,status\na@example.com,valid\n"), nil)
+	assert.NotNil(t, error_)
+
+	_, error_ = ParseScoringResults(strings.NewReader("email,score\na@example.com,high\n"), nil)
+	if assert.NotNil(t, error_) {
+		assert.Contains(t, error_.Error(), "a@example.com")
+	}
+}
+
+func TestScoringResultReader(t *testing.T) {
+	reader, error_ := NewScoringResultReader(strings.NewReader("\ufeff"+sample_banded_scoring_result), &ResultParseOptions{EmailAddressColumn: 2})
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{"Name", "Email", "ZeroBounceQualityScore"}, reader.Header())
+	assert.Equal(t, []string{"Name", "Email"}, reader.InputHeader())
+
+	row, error_ := reader.Read()
+	assert.Nil(t, error_)
+	assert.Equal(t, "jane@example.com", row.Email)
+	assert.Equal(t, 9.0, row.Score)
+	assert.Equal(t, []string{"Jane", "jane@example.com"}, row.Input)
+
+	reader.Read()
+	row, error_ = reader.Read()
+	assert.Nil(t, error_)
+	assert.Equal(t, "jim@example.com", row.Email)
+	assert.False(t, row.Scored)
+
+	reader.Read()
+	_, error_ = reader.Read()
+	assert.Equal(t, io.EOF, error_)
+
+	// submitted columns named after a score are kept as input
+	contents := "Lead Score,Email,ZeroBounceQualityScore\n42,jane@example.com,9\n"
+	reader, error_ = NewScoringResultReader(strings.NewReader(contents), &ResultParseOptions{EmailAddressColumn: 2})
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{"Lead Score", "Email"}, reader.InputHeader())
+	row, error_ = reader.Read()
+	assert.Nil(t, error_)
+	assert.Equal(t, 9.0, row.Score)
+	assert.Equal(t, []string{"42", "jane@example.com"}, row.Input)
+
+	reader, error_ = NewScoringResultReader(strings.NewReader("Lead Score,Email,Quality Score\n42,jane@example.com,9\n"), nil)
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{"Lead Score", "Email"}, reader.InputHeader())
+}
+
+func TestScoreBands(t *testing.T) {
+	bands := ScoreBands{{Name: "good", Min: 7}, {Name: "poor", Min: 1}, {Name: "fair", Min: 4}}
+	assert.Equal(t, SCORE_BAND_NONE, bands.Band(0.5))
+	assert.Equal(t, "poor", bands.Band(1))
+	assert.Equal(t, "fair", bands.Band(6.99))
+	assert.Equal(t, "good", bands.Band(10))
+	assert.Equal(t, SCORE_BAND_NONE, bands.RowBand(AiScoringRow{Score: 10}))
+
+	rows, _ := ParseScoringResults(strings.NewReader(sample_banded_scoring_result), &ResultParseOptions{EmailAddressColumn: 2})
+	buckets := BucketScoringRows(rows, nil)
+	assert.Len(t, buckets, 3)
+	if assert.Len(t, buckets["high"], 2) {
+		assert.Equal(t, "jane@example.com", buckets["high"][0].Email)
+		assert.Equal(t, "joe@example.com", buckets["high"][1].Email)
+	}
+	assert.Len(t, buckets["low"], 1)
+	assert.Len(t, buckets[SCORE_BAND_NONE], 1)
+}
+
+func TestWriteScoreBands(t *testing.T) {
+	outputs := map[string]*bufferCloser{}
+	counts, error_ := WriteScoreBands(strings.NewReader(sample_banded_scoring_result), ScoreBands{{Name: "keep", Min: 5}}, nil,
+		func(band string) (io.Writer, error) {
+			outputs[band] = &bufferCloser{}
+			return outputs[band], nil
+		},
+	)
+	assert.Nil(t, error_)
+	assert.Equal(t, map[string]int{"keep": 2, SCORE_BAND_NONE: 2}, counts)
+	assert.Equal(t, "Name,Email,ZeroBounceQualityScore\nJane,jane@example.com,9\nJoe,joe@example.com,8\n", outputs["keep"].String())
+	assert.Equal(t, "Name,Email,ZeroBounceQualityScore\nJohn,john@example.com,2\nJim,jim@example.com,\n", outputs[SCORE_BAND_NONE].String())
+	assert.True(t, outputs["keep"].closed)
+
+	_, error_ = WriteScoreBands(strings.NewReader(sample_banded_scoring_result), nil, nil,
+		func(band string) (io.Writer, error) { return nil, errors.New(sample_error_message) },
+	)
+	if assert.NotNil(t, error_) {
+		assert.Contains(t, error_.Error(), sample_error_message)
+	}
+
+	counts, error_ = WriteScoreBands(strings.NewReader(""), nil, nil, nil)
+	assert.Nil(t, error_)
+	assert.Empty(t, counts)
+}
+
+func TestWriteScoreBandFiles(t *testing.T) {
+	directory := t.TempDir()
+	counts, error_ := WriteScoreBandFiles(strings.NewReader(sample_banded_scoring_result), nil, nil, filepath.Join(directory, "scores_%s.csv"))
+	assert.Nil(t, error_)
+	assert.Equal(t, map[string]int{"high": 2, "low": 1, SCORE_BAND_NONE: 1}, counts)
+	contents, error_ := os.ReadFile(filepath.Join(directory, "scores_low.csv"))
+	assert.Nil(t, error_)
+	assert.Equal(t, "Name,Email,ZeroBounceQualityScore\nJohn,john@example.com,2\n", string(contents))
+	_, error_ = os.Stat(filepath.Join(directory, "scores_medium.csv"))
+	assert.True(t, os.IsNotExist(error_))
+
+	_, error_ = WriteScoreBandFiles(strings.NewReader(sample_banded_scoring_result), nil, nil, filepath.Join(directory, "scores.csv"))
+	assert.NotNil(t, error_)
+}
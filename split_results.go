@@ -0,0 +1,73 @@
+package zerobouncego
+
+import (
+	"io"
+)
+
+// RESULT_BUCKET_NONE - bucket of the validation rows without status
+const RESULT_BUCKET_NONE = "none"
+
+// ResultBucketFunc - name of the bucket a validation result row goes to
+type ResultBucketFunc func(row *BulkValidationRow) string
+
+// BucketByStatus - buckets rows by their final status (see
+// BulkValidationRow.Final), as in "valid" or "catch-all"
+func BucketByStatus(row *BulkValidationRow) string {
+	status := row.Final().Status
+	if status == "" {
+		return RESULT_BUCKET_NONE
+	}
+	return status
+}
+
+// BucketByPolicy - buckets rows by the verdict of a policy on their final
+// result: "accept", "reject" or "review"
+func BucketByPolicy(policy *Policy) ResultBucketFunc {
+	return func(row *BulkValidationRow) string {
+		response := row.Final()
+		return string(policy.Evaluate(&response).Verdict)
+	}
+}
+
+// WriteValidationBuckets - copy the rows of a bulk validation result file
+// into one csv output per bucket (BucketByStatus if no bucket function is
+// given), each starting with the header of the file. Outputs are opened on
+// their first row, and closed at the end when they implement io.Closer.
+// Returns the number of rows written by bucket.
+func WriteValidationBuckets(reader io.Reader, bucket ResultBucketFunc, options *ResultParseOptions, open func(bucket string) (io.Writer, error)) (map[string]int, error) {
+	if bucket == nil {
+		bucket = BucketByStatus
+	}
+	result_reader, error_ := NewValidationResultReader(reader, options)
+	if error_ == io.EOF {
+		return map[string]int{}, nil
+	}
+	if error_ != nil {
+		return nil, error_
+	}
+	splitter := newCsvSplitter(result_reader.Header(), open)
+	for {
+		row, error_ := result_reader.Read()
+		if error_ == io.EOF {
+			return splitter.counts, splitter.close()
+		}
+		if error_ == nil {
+			error_ = splitter.write(bucket(row), row.record)
+		}
+		if error_ != nil {
+			splitter.close()
+			return splitter.counts, error_
+		}
+	}
+}
+
+// WriteValidationBucketFiles - WriteValidationBuckets into files, named after
+// the given pattern in which "%s" stands for the bucket name (eg:
+// "contacts_%s.csv")
+func WriteValidationBucketFiles(reader io.Reader, bucket ResultBucketFunc, options *ResultParseOptions, path_pattern string) (map[string]int, error) {
+	open, error_ := patternFileOpener(path_pattern)
+	if error_ != nil {
+		return nil, error_
+	}
+	return WriteValidationBuckets(reader, bucket, options, open)
+}
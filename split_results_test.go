@@ -0,0 +1,73 @@
+package zerobouncego
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const sample_split_validation_result = "Email,ZB Status,ZB Sub Status\n" +
+	"jane@example.com,Valid,\n" +
+	"john@example.com,invalid,mailbox_not_found\n" +
+	"info@example.com,do_not_mail,role_based\n" +
+	"joe@example.com,valid,\n" +
+	"jim@example.com,,\n"
+
+func TestBucketByPolicy(t *testing.T) {
+	bucket := BucketByPolicy(PolicyBalanced())
+	row := &BulkValidationRow{ValidateResponse: ValidateResponse{Status: S_CATCH_ALL}}
+	assert.Equal(t, "review", bucket(row))
+	row.Phase2 = &ValidateResponse{Status: S_VALID}
+	assert.Equal(t, "accept", bucket(row))
+	assert.Equal(t, S_VALID, BucketByStatus(row))
+	assert.Equal(t, RESULT_BUCKET_NONE, BucketByStatus(&BulkValidationRow{}))
+}
+
+func TestWriteValidationBuckets(t *testing.T) {
+	outputs := map[string]*bufferCloser{}
+	open := func(bucket string) (io.Writer, error) {
+		outputs[bucket] = &bufferCloser{}
+		return outputs[bucket], nil
+	}
+	counts, error_ := WriteValidationBuckets(strings.NewReader(sample_split_validation_result), nil, nil, open)
+	assert.Nil(t, error_)
+	assert.Equal(t, map[string]int{S_VALID: 2, S_INVALID: 1, S_DO_NOT_MAIL: 1, RESULT_BUCKET_NONE: 1}, counts)
+	assert.Equal(t, "Email,ZB Status,ZB Sub Status\njane@example.com,Valid,\njoe@example.com,valid,\n", outputs[S_VALID].String())
+	assert.True(t, outputs[S_INVALID].closed)
+
+	outputs = map[string]*bufferCloser{}
+	counts, error_ = WriteValidationBuckets(strings.NewReader(sample_split_validation_result), BucketByPolicy(PolicyStrict()), nil, open)
+	assert.Nil(t, error_)
+	assert.Equal(t, map[string]int{"accept": 2, "reject": 3}, counts)
+	assert.Equal(t, "Email,ZB Status,ZB Sub Status\njohn@example.com,invalid,mailbox_not_found\n"+
+		"info@example.com,do_not_mail,role_based\njim@example.com,,\n", outputs["reject"].String())
+
+	_, error_ = WriteValidationBuckets(strings.NewReader(sample_split_validation_result), nil, nil,
+		func(bucket string) (io.Writer, error) { return nil, errors.New(sample_error_message) },
+	)
+	if assert.NotNil(t, error_) {
+		assert.Contains(t, error_.Error(), sample_error_message)
+	}
+
+	counts, error_ = WriteValidationBuckets(strings.NewReader(""), nil, nil, nil)
+	assert.Nil(t, error_)
+	assert.Empty(t, counts)
+}
+
+func TestWriteValidationBucketFiles(t *testing.T) {
+	directory := t.TempDir()
+	counts, error_ := WriteValidationBucketFiles(strings.NewReader(sample_split_validation_result), nil, nil, filepath.Join(directory, "contacts_%s.csv"))
+	assert.Nil(t, error_)
+	assert.Equal(t, 2, counts[S_VALID])
+	contents, error_ := os.ReadFile(filepath.Join(directory, "contacts_do_not_mail.csv"))
+	assert.Nil(t, error_)
+	assert.Equal(t, "Email,ZB Status,ZB Sub Status\ninfo@example.com,do_not_mail,role_based\n", string(contents))
+
+	_, error_ = WriteValidationBucketFiles(strings.NewReader(sample_split_validation_result), nil, nil, filepath.Join(directory, "contacts.csv"))
+	assert.NotNil(t, error_)
+}
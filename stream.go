@@ -0,0 +1,463 @@
+package zerobouncego
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// STREAM_DEFAULT_WORKERS - default number of concurrent validations (or batch
+// requests) of ValidateStream
+const STREAM_DEFAULT_WORKERS = 2
+
+// ErrMissingBatchResult - a batch validation response held neither a result
+// nor an error for an email
+var ErrMissingBatchResult = errors.New("batch validation returned no result for this email")
+
+// StreamSource - produces the emails of a stream, sending them into `emails`
+// until exhausted (returning nil) or failing; sources must stop when the
+// context is cancelled and must not close the channel
+type StreamSource func(ctx context.Context, emails chan<- EmailToValidate) error
+
+// StreamSink - consumes the results of a stream, one at a time; an error
+// stops the stream
+type StreamSink func(result StreamResult) error
+
+// StreamOptions - how ValidateStream validates the emails
+type StreamOptions struct {
+	// UseBatch validates emails through ValidateBatch, in groups of BatchSize,
+	// rather than one by one through ValidateWithTimeout
+	UseBatch bool
+	// BatchSize defaults to, and is capped at, BATCH_VALIDATE_MAX_EMAILS
+	BatchSize int
+	// Workers is the number of validations (or batch requests) in flight at
+	// once, defaults to STREAM_DEFAULT_WORKERS
+	Workers int
+	// RequestsPerMinute spaces out batch requests (defaults to
+	// BATCH_VALIDATE_REQUESTS_PER_MINUTE; negative disables the limit);
+	// single validations are not limited unless it is set
+	RequestsPerMinute int
+	// IPAddress is used for emails that come without one
+	IPAddress string
+	// Timeout is passed to ValidateWithTimeout (single validations only)
+	Timeout string
+}
+
+func (o StreamOptions) withDefaults() StreamOptions {
+	if o.BatchSize <= 0 || o.BatchSize > BATCH_VALIDATE_MAX_EMAILS {
+		o.BatchSize = BATCH_VALIDATE_MAX_EMAILS
+	}
+	if o.Workers <= 0 {
+		o.Workers = STREAM_DEFAULT_WORKERS
+	}
+	if o.RequestsPerMinute == 0 && o.UseBatch {
+		o.RequestsPerMinute = BATCH_VALIDATE_REQUESTS_PER_MINUTE
+	}
+	return o
+}
+
+// StreamResult - outcome of one streamed email; results are emitted as they
+// complete, use `Position` (0-based, in source order) to restore the order
+type StreamResult struct {
+	Position int
+	Input    EmailToValidate
+	Response *ValidateResponse
+	// BatchError is set when the batch endpoint reported an error for the email
+	BatchError *EmailBatchError
+	// Error is set when the email could not be validated
+	Error error
+}
+
+// StreamSummary - counts of a completed (or interrupted) stream
+type StreamSummary struct {
+	Total     int
+	Validated int
+	Failed    int
+	ByStatus  map[string]int
+}
+
+func (s *StreamSummary) add(result StreamResult) {
+	s.Total++
+	if result.Error != nil || result.Response == nil {
+		s.Failed++
+		return
+	}
+	s.Validated++
+	s.ByStatus[result.Response.Status]++
+}
+
+type streamItem struct {
+	position int
+	email    EmailToValidate
+}
+
+// ValidateStream - validate the emails produced by `source` with a bounded
+// number of workers and hand each result to `sink`. Only a few emails are
+// held in memory at once: a slow sink slows the workers down, which in turn
+// slow the source down. Validation failures are reported in the results and
+// do not stop the stream; source or sink errors and context cancellation do,
+// and are returned along with the summary of the results emitted so far.
+func ValidateStream(ctx context.Context, source StreamSource, sink StreamSink, options StreamOptions) (StreamSummary, error) {
+	options = options.withDefaults()
+	summary := StreamSummary{ByStatus: map[string]int{}}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// source -> items
+	emails := make(chan EmailToValidate)
+	source_error := make(chan error, 1)
+	go func() {
+		defer close(emails)
+		source_error <- source(ctx, emails)
+	}()
+	items := make(chan streamItem)
+	go func() {
+		defer close(items)
+		position := 0
+		for email := range emails {
+			if email.IPAddress == "" {
+				email.IPAddress = options.IPAddress
+			}
+			select {
+			case items <- streamItem{position: position, email: email}:
+				position++
+			case <-ctx.Done():
+				for range emails {
+				}
+				return
+			}
+		}
+	}()
+
+	// items -> results
+	results := make(chan StreamResult, options.Workers)
+	limiter := newRateLimiter(options.RequestsPerMinute)
+	var wait_group sync.WaitGroup
+	if options.UseBatch {
+		batches := make(chan []streamItem)
+		go groupStreamItems(ctx, items, batches, options.BatchSize)
+		for worker := 0; worker < options.Workers; worker++ {
+			wait_group.Add(1)
+			go func() {
+				defer wait_group.Done()
+				for batch := range batches {
+					if ctx.Err() != nil {
+						continue
+					}
+					limiter.wait()
+					for _, result := range validateStreamBatch(batch, options.BatchSize) {
+						if !sendStreamResult(ctx, results, result) {
+							break
+						}
+					}
+				}
+			}()
+		}
+	} else {
+		for worker := 0; worker < options.Workers; worker++ {
+			wait_group.Add(1)
+			go func() {
+				defer wait_group.Done()
+				for item := range items {
+					if ctx.Err() != nil {
+						continue
+					}
+					limiter.wait()
+					result := StreamResult{Position: item.position, Input: item.email}
+					result.Response, result.Error = ValidateWithTimeout(item.email.EmailAddress, item.email.IPAddress, options.Timeout)
+					if result.Error != nil {
+						result.Response = nil
+					}
+					sendStreamResult(ctx, results, result)
+				}
+			}()
+		}
+	}
+	go func() {
+		wait_group.Wait()
+		close(results)
+	}()
+
+	// results -> sink
+	var error_ error
+	for result := range results {
+		if error_ != nil {
+			continue
+		}
+		summary.add(result)
+		if sink_error := sink(result); sink_error != nil {
+			error_ = fmt.Errorf("stream sink: %w", sink_error)
+			cancel()
+		}
+	}
+	source_failure := <-source_error
+	if error_ == nil && ctx.Err() != nil {
+		error_ = ctx.Err()
+	}
+	if error_ == nil && source_failure != nil {
+		error_ = fmt.Errorf("stream source: %w", source_failure)
+	}
+	return summary, error_
+}
+
+// ValidateStreamToChannel - same as ValidateStream, emitting the results on a
+// channel which is closed once the stream ends; the summary and error are
+// delivered on the second channel afterwards
+func ValidateStreamToChannel(ctx context.Context, source StreamSource, options StreamOptions) (<-chan StreamResult, <-chan StreamOutcome) {
+	results := make(chan StreamResult)
+	outcome := make(chan StreamOutcome, 1)
+	go func() {
+		summary, error_ := ValidateStream(ctx, source, ChannelSink(ctx, results), options)
+		close(results)
+		outcome <- StreamOutcome{Summary: summary, Error: error_}
+		close(outcome)
+	}()
+	return results, outcome
+}
+
+// StreamOutcome - final summary and error of ValidateStreamToChannel
+type StreamOutcome struct {
+	Summary StreamSummary
+	Error   error
+}
+
+func sendStreamResult(ctx context.Context, results chan<- StreamResult, result StreamResult) bool {
+	select {
+	case results <- result:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// groupStreamItems - gather items into batches of up to `size` emails
+func groupStreamItems(ctx context.Context, items <-chan streamItem, batches chan<- []streamItem, size int) {
+	defer close(batches)
+	var batch []streamItem
+	for item := range items {
+		batch = append(batch, item)
+		if len(batch) < size {
+			continue
+		}
+		select {
+		case batches <- batch:
+			batch = nil
+		case <-ctx.Done():
+			for range items {
+			}
+			return
+		}
+	}
+	if len(batch) > 0 {
+		select {
+		case batches <- batch:
+		case <-ctx.Done():
+		}
+	}
+}
+
+// validateStreamBatch - validate a batch and correlate its results with the
+// streamed items
+func validateStreamBatch(batch []streamItem, batch_size int) []StreamResult {
+	entries := make([]BatchEntry, len(batch))
+	for index, item := range batch {
+		entries[index] = BatchEntry{Key: strconv.Itoa(item.position), EmailToValidate: item.email}
+	}
+	correlated, error_ := ValidateBatchEntries(entries, BatchOptions{ChunkSize: batch_size, RequestsPerMinute: -1})
+
+	results := make([]StreamResult, len(batch))
+	for index, batch_result := range correlated.Results {
+		result := StreamResult{Position: batch[index].position, Input: batch_result.Input}
+		switch {
+		case batch_result.Response != nil:
+			result.Response = batch_result.Response
+		case batch_result.Error != nil:
+			result.BatchError = batch_result.Error
+			result.Error = errors.New(batch_result.Error.Error)
+		case error_ != nil:
+			result.Error = error_
+		case len(correlated.UnmatchedErrors) > 0:
+			result.Error = errors.New(correlated.UnmatchedErrors[0].Error)
+		default:
+			result.Error = ErrMissingBatchResult
+		}
+		results[index] = result
+	}
+	return results
+}
+
+// ChannelSource - stream the emails received on a channel, until it is closed
+func ChannelSource(channel <-chan EmailToValidate) StreamSource {
+	return func(ctx context.Context, emails chan<- EmailToValidate) error {
+		for {
+			select {
+			case email, ok := <-channel:
+				if !ok {
+					return nil
+				}
+				if error_ := sendStreamEmail(ctx, emails, email); error_ != nil {
+					return error_
+				}
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// LinesSource - stream one email per line of a reader; blank lines are skipped
+func LinesSource(reader io.Reader) StreamSource {
+	return func(ctx context.Context, emails chan<- EmailToValidate) error {
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" {
+				continue
+			}
+			if error_ := sendStreamEmail(ctx, emails, EmailToValidate{EmailAddress: line}); error_ != nil {
+				return error_
+			}
+		}
+		return scanner.Err()
+	}
+}
+
+// CsvSource - stream the emails (and IP addresses, if `IpAddressColumn` is
+// set) of a csv file, as described by its `File`, `HasHeaderRow`,
+// `EmailAddressColumn` and `IpAddressColumn` fields; rows without an email
+// are skipped
+func CsvSource(csv_file CsvFile) StreamSource {
+	return func(ctx context.Context, emails chan<- EmailToValidate) error {
+		if csv_file.File == nil {
+			return errors.New("csv file has no contents")
+		}
+		if csv_file.EmailAddressColumn < 1 {
+			return fmt.Errorf("invalid email address column %d", csv_file.EmailAddressColumn)
+		}
+		reader := csv.NewReader(csv_file.File)
+		reader.FieldsPerRecord = -1
+		reader.LazyQuotes = true
+		reader.ReuseRecord = true
+		is_header := csv_file.HasHeaderRow
+		for {
+			record, error_ := reader.Read()
+			if error_ == io.EOF {
+				return nil
+			}
+			if error_ != nil {
+				return errors.New("error reading from csv file: " + error_.Error())
+			}
+			if is_header {
+				is_header = false
+				continue
+			}
+			email := EmailToValidate{
+				EmailAddress: strings.TrimSpace(csvColumn(record, csv_file.EmailAddressColumn)),
+				IPAddress:    strings.TrimSpace(csvColumn(record, csv_file.IpAddressColumn)),
+			}
+			if email.EmailAddress == "" {
+				continue
+			}
+			if error_ := sendStreamEmail(ctx, emails, email); error_ != nil {
+				return error_
+			}
+		}
+	}
+}
+
+// csvColumn - value of a 1-based column of a record ("" if absent)
+func csvColumn(record []string, column int) string {
+	if column < 1 || column > len(record) {
+		return ""
+	}
+	return record[column-1]
+}
+
+func sendStreamEmail(ctx context.Context, emails chan<- EmailToValidate, email EmailToValidate) error {
+	select {
+	case emails <- email:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ChannelSink - send each result on a channel, blocking while it is full
+// (the channel is not closed by the stream)
+func ChannelSink(ctx context.Context, channel chan<- StreamResult) StreamSink {
+	return func(result StreamResult) error {
+		select {
+		case channel <- result:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// streamRecord - JSON representation of a StreamResult
+type streamRecord struct {
+	Position  int               `json:"position"`
+	Email     string            `json:"email_address"`
+	IPAddress string            `json:"ip_address,omitempty"`
+	Result    *ValidateResponse `json:"result"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// JSONLinesSink - write each result as one JSON object per line
+func JSONLinesSink(writer io.Writer) StreamSink {
+	encoder := json.NewEncoder(writer)
+	return func(result StreamResult) error {
+		record := streamRecord{
+			Position:  result.Position,
+			Email:     result.Input.EmailAddress,
+			IPAddress: result.Input.IPAddress,
+			Result:    result.Response,
+		}
+		if result.Error != nil {
+			record.Error = result.Error.Error()
+		}
+		return encoder.Encode(record)
+	}
+}
+
+// STREAM_CSV_HEADER - columns written by CsvSink
+var STREAM_CSV_HEADER = []string{
+	"position", "email_address", "ip_address", "status", "sub_status",
+	"free_email", "did_you_mean", "domain", "mx_found", "error",
+}
+
+// CsvSink - write each result as a csv row (see STREAM_CSV_HEADER, written
+// before the first row)
+func CsvSink(writer io.Writer) StreamSink {
+	csv_writer := csv.NewWriter(writer)
+	header_written := false
+	return func(result StreamResult) error {
+		if !header_written {
+			header_written = true
+			csv_writer.Write(STREAM_CSV_HEADER)
+		}
+		row := []string{strconv.Itoa(result.Position), result.Input.EmailAddress, result.Input.IPAddress}
+		if response := result.Response; response != nil {
+			row = append(row, response.Status, response.SubStatus, strconv.FormatBool(response.FreeEmail),
+				response.DidYouMean.String, response.Domain, response.MxFound)
+		} else {
+			row = append(row, "", "", "", "", "", "")
+		}
+		if result.Error != nil {
+			row = append(row, result.Error.Error())
+		} else {
+			row = append(row, "")
+		}
+		csv_writer.Write(row)
+		csv_writer.Flush()
+		return csv_writer.Error()
+	}
+}
@@ -0,0 +1,186 @@
+package zerobouncego
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func expectedStatusCounts() map[string]int {
+	counts := map[string]int{}
+	for _, test_case := range emailsToValidate {
+		counts[test_case.Status]++
+	}
+	return counts
+}
+
+func TestValidateStreamLinesToJSONLines(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	mockValidateRequest()
+
+	lines := &strings.Builder{}
+	for _, test_case := range emailsToValidate {
+		lines.WriteString(test_case.Email + "\n\n")
+	}
+	output := &bytes.Buffer{}
+	summary, error_ := ValidateStream(context.Background(), LinesSource(strings.NewReader(lines.String())),
+		JSONLinesSink(output), StreamOptions{Workers: 3, IPAddress: SANDBOX_IP})
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	assert.Equal(t, len(emailsToValidate), summary.Total)
+	assert.Equal(t, len(emailsToValidate), summary.Validated)
+	assert.Equal(t, 0, summary.Failed)
+	assert.Equal(t, expectedStatusCounts(), summary.ByStatus)
+
+	positions := map[int]bool{}
+	scanner := bufio.NewScanner(output)
+	for scanner.Scan() {
+		record := streamRecord{}
+		if !assert.Nil(t, json.Unmarshal(scanner.Bytes(), &record)) {
+			continue
+		}
+		positions[record.Position] = true
+		assert.Equal(t, emailsToValidate[record.Position].Email, record.Email)
+		assert.Equal(t, SANDBOX_IP, record.IPAddress)
+		if assert.NotNil(t, record.Result) {
+			assert.Equal(t, emailsToValidate[record.Position].Status, record.Result.Status)
+		}
+	}
+	assert.Len(t, positions, len(emailsToValidate))
+}
+
+func TestValidateStreamCsvBatchToCsv(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	mockBatchValidateRequest()
+
+	input := &strings.Builder{}
+	input.WriteString("id,email,ip\n")
+	for index, test_case := range emailsToValidate {
+		input.WriteString(strings.Repeat("x", index) + "," + test_case.Email + "," + SANDBOX_IP + "\n")
+	}
+	input.WriteString("empty,,\n")
+	output := &bytes.Buffer{}
+	source := CsvSource(CsvFile{File: strings.NewReader(input.String()), HasHeaderRow: true, EmailAddressColumn: 2, IpAddressColumn: 3})
+	summary, error_ := ValidateStream(context.Background(), source, CsvSink(output),
+		StreamOptions{UseBatch: true, BatchSize: 4, RequestsPerMinute: -1})
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	assert.Equal(t, len(emailsToValidate), summary.Validated)
+	assert.Equal(t, expectedStatusCounts(), summary.ByStatus)
+
+	rows, error_ := csv.NewReader(output).ReadAll()
+	assert.Nil(t, error_)
+	if assert.Len(t, rows, len(emailsToValidate)+1) {
+		assert.Equal(t, STREAM_CSV_HEADER, rows[0])
+		for _, row := range rows[1:] {
+			assert.Equal(t, SANDBOX_IP, row[2])
+			assert.NotEmpty(t, row[3])
+			assert.Empty(t, row[9])
+		}
+	}
+}
+
+func TestValidateStreamChannels(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	mockValidateRequest()
+
+	emails := make(chan EmailToValidate)
+	go func() {
+		defer close(emails)
+		emails <- EmailToValidate{EmailAddress: "valid@example.com"}
+		emails <- EmailToValidate{EmailAddress: "not-mocked@example.com"}
+	}()
+	results, outcome := ValidateStreamToChannel(context.Background(), ChannelSource(emails), StreamOptions{})
+	var received []StreamResult
+	for result := range results {
+		received = append(received, result)
+	}
+	final := <-outcome
+	assert.Nil(t, final.Error)
+	assert.Len(t, received, 2)
+	assert.Equal(t, 2, final.Summary.Total)
+	assert.Equal(t, 1, final.Summary.Failed)
+	assert.Equal(t, map[string]int{S_VALID: 1}, final.Summary.ByStatus)
+	for _, result := range received {
+		if result.Input.EmailAddress == "not-mocked@example.com" {
+			assert.NotNil(t, result.Error)
+			assert.Nil(t, result.Response)
+		}
+	}
+}
+
+// endlessSource - source producing the same email until cancelled
+func endlessSource(ctx context.Context, emails chan<- EmailToValidate) error {
+	for {
+		if error_ := sendStreamEmail(ctx, emails, EmailToValidate{EmailAddress: "valid@example.com"}); error_ != nil {
+			return error_
+		}
+	}
+}
+
+func TestValidateStreamCancellation(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	mockValidateRequest()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	received := 0
+	sink := func(result StreamResult) error {
+		received++
+		if received == 5 {
+			cancel()
+		}
+		return nil
+	}
+	done := make(chan struct{})
+	var error_ error
+	var summary StreamSummary
+	go func() {
+		summary, error_ = ValidateStream(ctx, endlessSource, sink, StreamOptions{Workers: 2})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream did not stop after cancellation")
+	}
+	assert.True(t, errors.Is(error_, context.Canceled))
+	assert.GreaterOrEqual(t, summary.Total, 5)
+}
+
+func TestValidateStreamSinkAndSourceErrors(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	mockValidateRequest()
+
+	sink_failure := errors.New("disk full")
+	summary, error_ := ValidateStream(context.Background(), endlessSource,
+		func(StreamResult) error { return sink_failure }, StreamOptions{})
+	assert.True(t, errors.Is(error_, sink_failure))
+	assert.Equal(t, 1, summary.Total)
+
+	_, error_ = ValidateStream(context.Background(), CsvSource(CsvFile{File: strings.NewReader("a\n")}),
+		func(StreamResult) error { return nil }, StreamOptions{})
+	if assert.NotNil(t, error_) {
+		assert.Contains(t, error_.Error(), "stream source: invalid email address column")
+	}
+}
@@ -0,0 +1,112 @@
+package zerobouncego
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// TRANSFER_IDLE_TIMEOUT - how long a file upload or result download may go
+// without any progress (connecting, sending, waiting for the response or
+// receiving it) before being aborted; unlike other requests, their total
+// duration is not bounded
+var TRANSFER_IDLE_TIMEOUT = httpTimeout
+
+// transferHTTPClient - client for requests streaming files, without the
+// overall timeout of timedHTTPClient, which large files would exceed
+func transferHTTPClient() *http.Client {
+	c := *http.DefaultClient
+	c.Timeout = 0
+	return &c
+}
+
+// idleWatchdog - cancels a request once it made no progress for
+// TRANSFER_IDLE_TIMEOUT
+type idleWatchdog struct {
+	timer   *time.Timer
+	cancel  context.CancelFunc
+	expired int32
+}
+
+func newIdleWatchdog(cancel context.CancelFunc) *idleWatchdog {
+	watchdog := &idleWatchdog{cancel: cancel}
+	watchdog.timer = time.AfterFunc(TRANSFER_IDLE_TIMEOUT, func() {
+		atomic.StoreInt32(&watchdog.expired, 1)
+		cancel()
+	})
+	return watchdog
+}
+
+// progress - restart the countdown
+func (w *idleWatchdog) progress() {
+	w.timer.Reset(TRANSFER_IDLE_TIMEOUT)
+}
+
+func (w *idleWatchdog) stop() {
+	w.timer.Stop()
+	w.cancel()
+}
+
+// wrap - the error of an aborted request, telling it timed out
+func (w *idleWatchdog) wrap(error_ error) error {
+	if error_ != nil && error_ != io.EOF && atomic.LoadInt32(&w.expired) == 1 {
+		return fmt.Errorf("no progress for %s: %w", TRANSFER_IDLE_TIMEOUT, error_)
+	}
+	return error_
+}
+
+// watchedBody - request or response body whose reads count as progress
+type watchedBody struct {
+	io.ReadCloser
+	watchdog *idleWatchdog
+	// stops the watchdog on close (response bodies)
+	owner bool
+}
+
+func (w *watchedBody) Read(buffer []byte) (int, error) {
+	read, error_ := w.ReadCloser.Read(buffer)
+	if read > 0 {
+		w.watchdog.progress()
+	}
+	return read, w.watchdog.wrap(error_)
+}
+
+func (w *watchedBody) Close() error {
+	if w.owner {
+		defer w.watchdog.stop()
+	}
+	return w.ReadCloser.Close()
+}
+
+// transferGet - GET request downloading a file, see doTransferRequest
+func transferGet(url_to_request string) (*http.Response, error) {
+	request, error_ := http.NewRequest("GET", url_to_request, nil)
+	if error_ != nil {
+		return nil, error_
+	}
+	return doTransferRequest(request)
+}
+
+// doTransferRequest - perform a request streaming a file with
+// transferHTTPClient, aborting it after TRANSFER_IDLE_TIMEOUT without
+// progress; the response body must be closed
+func doTransferRequest(request *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(request.Context())
+	watchdog := newIdleWatchdog(cancel)
+	request = request.WithContext(ctx)
+	if request.Body != nil && request.Body != http.NoBody {
+		request.Body = &watchedBody{ReadCloser: request.Body, watchdog: watchdog}
+	}
+	response, error_ := transferHTTPClient().Do(request)
+	if error_ != nil {
+		error_ = watchdog.wrap(error_)
+		watchdog.stop()
+		return nil, error_
+	}
+	watchdog.progress()
+	response.Body = &watchedBody{ReadCloser: response.Body, watchdog: watchdog, owner: true}
+	return response, nil
+}
@@ -0,0 +1,85 @@
+package zerobouncego
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// slowServer - server sending `chunks` chunks of "data" `interval` apart,
+// then stalling for `stall`
+func slowServer(chunks int, interval, stall time.Duration) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		for index := 0; index < chunks; index++ {
+			writer.Write([]byte("data"))
+			writer.(http.Flusher).Flush()
+			select {
+			case <-time.After(interval):
+			case <-request.Context().Done():
+				return
+			}
+		}
+		select {
+		case <-time.After(stall):
+		case <-request.Context().Done():
+		}
+	}))
+}
+
+func TestTransferOutlastsIdleTimeout(t *testing.T) {
+	TRANSFER_IDLE_TIMEOUT = 100 * time.Millisecond
+	defer func() { TRANSFER_IDLE_TIMEOUT = httpTimeout }()
+	server := slowServer(10, 20*time.Millisecond, 0)
+	defer server.Close()
+
+	// 200ms in total, but never idle for long
+	response, error_ := transferGet(server.URL)
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	contents, error_ := io.ReadAll(response.Body)
+	response.Body.Close()
+	assert.Nil(t, error_)
+	assert.Equal(t, strings.Repeat("data", 10), string(contents))
+}
+
+func TestTransferIdleTimeout(t *testing.T) {
+	TRANSFER_IDLE_TIMEOUT = 50 * time.Millisecond
+	defer func() { TRANSFER_IDLE_TIMEOUT = httpTimeout }()
+	server := slowServer(1, 0, 5*time.Second)
+	defer server.Close()
+
+	response, error_ := transferGet(server.URL)
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	defer response.Body.Close()
+	_, error_ = io.ReadAll(response.Body)
+	if assert.NotNil(t, error_) {
+		assert.Contains(t, error_.Error(), "no progress for 50ms")
+	}
+}
+
+func TestTransferUpload(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		contents, _ := io.ReadAll(request.Body)
+		writer.WriteHeader(201)
+		writer.Write([]byte(strings.ToUpper(string(contents))))
+	}))
+	defer server.Close()
+
+	request, _ := http.NewRequest("POST", server.URL, io.NopCloser(strings.NewReader("email\njane@example.com\n")))
+	response, error_ := doTransferRequest(request)
+	if !assert.Nil(t, error_) {
+		t.FailNow()
+	}
+	defer response.Body.Close()
+	contents, _ := io.ReadAll(response.Body)
+	assert.Equal(t, 201, response.StatusCode)
+	assert.Equal(t, "EMAIL\nJANE@EXAMPLE.COM\n", string(contents))
+}
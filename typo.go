@@ -0,0 +1,339 @@
+package zerobouncego
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"sync"
+
+	"gopkg.in/guregu/null.v4"
+)
+
+// POPULAR_MAIL_DOMAINS - bundled list of popular mailbox providers used by the
+// typo suggester; callers can extend it through `TypoSuggester.AddDomains`
+var POPULAR_MAIL_DOMAINS = []string{
+	"gmail.com", "googlemail.com", "yahoo.com", "yahoo.co.uk", "yahoo.fr", "yahoo.de",
+	"yahoo.es", "yahoo.it", "yahoo.ca", "yahoo.com.br", "ymail.com", "rocketmail.com",
+	"hotmail.com", "hotmail.co.uk", "hotmail.fr", "hotmail.de", "hotmail.it", "hotmail.es",
+	"outlook.com", "outlook.fr", "outlook.de", "live.com", "live.co.uk", "live.fr", "msn.com",
+	"aol.com", "icloud.com", "me.com", "mac.com", "protonmail.com", "proton.me", "pm.me",
+	"gmx.com", "gmx.de", "gmx.net", "web.de", "t-online.de", "mail.com", "email.com",
+	"zoho.com", "yandex.com", "yandex.ru", "mail.ru", "inbox.ru", "list.ru", "bk.ru",
+	"qq.com", "163.com", "126.com", "sina.com", "naver.com", "daum.net", "hanmail.net",
+	"comcast.net", "verizon.net", "att.net", "sbcglobal.net", "bellsouth.net", "cox.net",
+	"charter.net", "earthlink.net", "optonline.net", "btinternet.com", "sky.com",
+	"virginmedia.com", "orange.fr", "wanadoo.fr", "free.fr", "laposte.net", "sfr.fr",
+	"libero.it", "virgilio.it", "tiscali.it", "rediffmail.com", "uol.com.br", "bol.com.br",
+	"terra.com.br", "shaw.ca", "rogers.com", "sympatico.ca", "bigpond.com", "optusnet.com.au",
+	"xtra.co.nz", "fastmail.com", "hey.com", "tutanota.com", "seznam.cz", "wp.pl", "o2.pl",
+	"interia.pl", "onet.pl",
+}
+
+// POPULAR_TLDS - bundled list of top level domains (including common second
+// level ones) used to correct the end of a domain
+var POPULAR_TLDS = []string{
+	"com", "net", "org", "edu", "gov", "mil", "info", "biz", "io", "co", "me", "us", "uk",
+	"co.uk", "org.uk", "ac.uk", "ca", "de", "fr", "it", "es", "nl", "be", "ch", "at", "se",
+	"no", "dk", "fi", "pl", "cz", "pt", "ie", "ru", "ua", "jp", "co.jp", "cn", "com.cn",
+	"kr", "co.kr", "in", "co.in", "au", "com.au", "nz", "co.nz", "br", "com.br", "mx",
+	"com.mx", "ar", "com.ar", "za", "co.za", "sg", "com.sg", "hk", "tw", "eu", "app", "dev",
+	"ai", "tv", "cc", "ly", "gg", "fm", "am", "im", "is", "li", "lu", "to", "ws", "gr", "hu",
+	"ro", "bg", "hr", "si", "sk", "lt", "lv", "ee", "tr", "il", "ae", "sa", "eg", "ng", "ke",
+	"cl", "pe", "ve", "uy", "th", "vn", "ph", "my", "id", "pk", "bd", "lk", "kz", "by", "xyz",
+	"site", "online", "tech", "store", "shop", "club", "pro", "name", "mobi", "email", "cloud",
+}
+
+const (
+	// default maximum edit distance for a domain to be considered a typo
+	TYPO_DEFAULT_MAX_DISTANCE = 2
+	// default minimum length of the name of a domain (its label before the
+	// top level domain) to be compared to the known domains
+	TYPO_DEFAULT_MIN_NAME_LENGTH = 5
+)
+
+// TYPO_PRESCREEN - when enabled, Validate and ValidateWithTimeout look up a
+// suggestion for the address before calling the API, and set it as
+// `DidYouMean` on the response when the API did not suggest anything; the
+// address is still validated, a suggestion not meaning it is invalid
+var TYPO_PRESCREEN = false
+
+// TypoSuggester - offline domain typo suggester based on the edit distance
+// (with transpositions) to a list of known domains and top level domains
+type TypoSuggester struct {
+	// MaxDistance is the largest edit distance considered a typo; distances
+	// are further limited for short domains to avoid false suggestions
+	MaxDistance int
+	// MinNameLength is the length under which the name of a domain (eg:
+	// "ge" for "ge.com") is too short to tell a typo from another real
+	// domain; only the top level domain of such domains is corrected
+	MinNameLength int
+
+	// known values, mapped to their insertion rank (bundled lists are ordered
+	// by popularity, which breaks ties between equally close candidates)
+	mutex   sync.RWMutex
+	domains map[string]int
+	tlds    map[string]int
+}
+
+// NewTypoSuggester - create a suggester holding the bundled domains and TLDs
+func NewTypoSuggester() *TypoSuggester {
+	suggester := &TypoSuggester{
+		MaxDistance:   TYPO_DEFAULT_MAX_DISTANCE,
+		MinNameLength: TYPO_DEFAULT_MIN_NAME_LENGTH,
+		domains:       make(map[string]int),
+		tlds:          make(map[string]int),
+	}
+	suggester.AddDomains(POPULAR_MAIL_DOMAINS...)
+	suggester.AddTLDs(POPULAR_TLDS...)
+	return suggester
+}
+
+var defaultTypoSuggester = NewTypoSuggester()
+
+// DefaultTypoSuggester - the suggester used by `SuggestEmail` and `TYPO_PRESCREEN`
+func DefaultTypoSuggester() *TypoSuggester {
+	return defaultTypoSuggester
+}
+
+// AddDomains - add known (correctly spelled) domains
+func (s *TypoSuggester) AddDomains(domains ...string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		if _, known := s.domains[domain]; domain != "" && !known {
+			s.domains[domain] = len(s.domains)
+		}
+	}
+}
+
+// AddTLDs - add known top level domains (eg: "com", "co.uk")
+func (s *TypoSuggester) AddTLDs(tlds ...string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, tld := range tlds {
+		tld = strings.Trim(strings.ToLower(strings.TrimSpace(tld)), ".")
+		if _, known := s.tlds[tld]; tld != "" && !known {
+			s.tlds[tld] = len(s.tlds)
+		}
+	}
+}
+
+// LoadDomains - add known domains read from a reader, one per line; empty
+// lines and lines starting with "#" are skipped
+func (s *TypoSuggester) LoadDomains(reader io.Reader) error {
+	scanner := bufio.NewScanner(reader)
+	var domains []string
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		domains = append(domains, line)
+	}
+	if error_ := scanner.Err(); error_ != nil {
+		return error_
+	}
+	s.AddDomains(domains...)
+	return nil
+}
+
+// maxDistanceFor - allowed edit distance for a domain of the given length
+func (s *TypoSuggester) maxDistanceFor(length int) int {
+	max_distance := s.MaxDistance
+	if length < 10 && max_distance > 1 {
+		max_distance = 1
+	}
+	return max_distance
+}
+
+// publicSuffix - the longest known top level domain a domain ends with or,
+// failing that, its last label when two letters long (most are country
+// codes); "" otherwise
+func (s *TypoSuggester) publicSuffix(domain string) string {
+	labels := strings.Split(domain, ".")
+	for index := 1; index < len(labels); index++ {
+		suffix := strings.Join(labels[index:], ".")
+		if _, known := s.tlds[suffix]; known {
+			return suffix
+		}
+	}
+	if last_label := labels[len(labels)-1]; len(labels) > 1 && len(last_label) == 2 {
+		return last_label
+	}
+	return ""
+}
+
+// domainName - the label of a domain before its public suffix (eg: "yahoo"
+// for "yahoo.co.uk"), or before its last label without known suffix
+func (s *TypoSuggester) domainName(domain string) string {
+	name := domain
+	if suffix := s.publicSuffix(domain); suffix != "" {
+		name = strings.TrimSuffix(domain, "."+suffix)
+	} else if index := strings.LastIndex(domain, "."); index >= 0 {
+		name = domain[:index]
+	}
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// SuggestDomain - suggest a known domain for a misspelled one; the result is
+// null when the domain is known or no close enough match exists. Domains
+// whose name is shorter than MinNameLength (eg: "ge.com", a step away from
+// "me.com") are only checked for misspelled top level domains.
+func (s *TypoSuggester) SuggestDomain(domain string) null.String {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	if domain == "" {
+		return null.String{}
+	}
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if _, known := s.domains[domain]; known {
+		return null.String{}
+	}
+
+	// closest known domain; when the domain ends with a known suffix, only
+	// the domains sharing it are candidates (eg: "yahoo.co.jp" is not a typo
+	// of "yahoo.co.uk")
+	candidates := s.domains
+	if len(s.domainName(domain)) < s.MinNameLength {
+		candidates = nil
+	} else if suffix := s.publicSuffix(domain); suffix != "" {
+		candidates = make(map[string]int)
+		for candidate, rank := range s.domains {
+			if s.publicSuffix(candidate) == suffix {
+				candidates[candidate] = rank
+			}
+		}
+	}
+	if best_domain := closestCandidate(domain, candidates, s.maxDistanceFor(len(domain))); best_domain != "" {
+		return null.StringFrom(best_domain)
+	}
+
+	// otherwise, correct the top level domain only
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return null.String{}
+	}
+	last_label := labels[len(labels)-1]
+	// two letter labels are too ambiguous to be corrected (most are country codes)
+	if len(last_label) < 3 {
+		return null.String{}
+	}
+	if _, known := s.tlds[last_label]; known {
+		return null.String{}
+	}
+	if _, known := s.tlds[labels[len(labels)-2]+"."+last_label]; known && len(labels) > 2 {
+		return null.String{}
+	}
+	best_tld := closestCandidate(last_label, s.tlds, 1)
+	if best_tld == "" || strings.Contains(best_tld, ".") {
+		return null.String{}
+	}
+	return null.StringFrom(strings.Join(labels[:len(labels)-1], ".") + "." + best_tld)
+}
+
+// closestCandidate - the candidate closest to `value` within `max_distance`
+// edits; ties prefer candidates of the same length, then the lowest rank
+func closestCandidate(value string, candidates map[string]int, max_distance int) string {
+	best, best_distance := "", max_distance+1
+	for candidate, rank := range candidates {
+		distance := editDistance(value, candidate, best_distance+1)
+		if distance > best_distance || distance == best_distance && best == "" {
+			continue
+		}
+		if distance == best_distance {
+			same_length, best_same_length := len(candidate) == len(value), len(best) == len(value)
+			if same_length != best_same_length {
+				if !same_length {
+					continue
+				}
+			} else if rank > candidates[best] {
+				continue
+			}
+		}
+		best, best_distance = candidate, distance
+	}
+	return best
+}
+
+// SuggestEmail - suggest a corrected address, in the same shape as
+// `ValidateResponse.DidYouMean` (null when no correction is suggested)
+func (s *TypoSuggester) SuggestEmail(email string) null.String {
+	local_part, domain, ok := splitEmail(strings.TrimSpace(email))
+	if !ok || local_part == "" {
+		return null.String{}
+	}
+	suggestion := s.SuggestDomain(domain)
+	if !suggestion.Valid {
+		return suggestion
+	}
+	return null.StringFrom(local_part + "@" + suggestion.String)
+}
+
+// SuggestEmail - suggest a corrected address using the default suggester
+func SuggestEmail(email string) null.String {
+	return defaultTypoSuggester.SuggestEmail(email)
+}
+
+// applyTypoSuggestion - set a local suggestion as the `DidYouMean` of a
+// response without one from the API
+func applyTypoSuggestion(response *ValidateResponse, suggestion null.String) {
+	if response != nil && suggestion.Valid && !response.DidYouMean.Valid {
+		response.DidYouMean = suggestion
+	}
+}
+
+// editDistance - optimal string alignment distance (Levenshtein distance that
+// also counts adjacent transpositions as one edit); computation stops early
+// once the distance is known to reach `limit`
+func editDistance(first, second string, limit int) int {
+	a, b := []rune(first), []rune(second)
+	length_difference := len(a) - len(b)
+	if length_difference < 0 {
+		length_difference = -length_difference
+	}
+	if length_difference >= limit {
+		return limit
+	}
+
+	previous_previous := make([]int, len(b)+1)
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		row_minimum := current[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			value := previous[j] + 1
+			if insertion := current[j-1] + 1; insertion < value {
+				value = insertion
+			}
+			if substitution := previous[j-1] + cost; substitution < value {
+				value = substitution
+			}
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				if transposition := previous_previous[j-2] + 1; transposition < value {
+					value = transposition
+				}
+			}
+			current[j] = value
+			if value < row_minimum {
+				row_minimum = value
+			}
+		}
+		if row_minimum >= limit {
+			return limit
+		}
+		previous_previous, previous, current = previous, current, previous_previous
+	}
+	if previous[len(b)] > limit {
+		return limit
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,102 @@
+package zerobouncego
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuggestEmail(t *testing.T) {
+	test_cases := map[string]string{
+		"user@gmial.com":       "user@gmail.com",
+		"user@GMAIL.CON":       "user@gmail.com",
+		"user@hotmial.com":     "user@hotmail.com",
+		"user@yahooo.com":      "user@yahoo.com",
+		"user@outlok.com":      "user@outlook.com",
+		"user@gmaill.con":      "user@gmail.com",
+		"user@example.cmo":     "user@example.com",
+		"user@example.co.ukk":  "user@example.co.uk",
+		"user@sub.example.nte": "user@sub.example.net",
+	}
+	for email, suggestion := range test_cases {
+		result := SuggestEmail(email)
+		assert.Truef(t, result.Valid, "no suggestion for %s", email)
+		assert.Equalf(t, suggestion, result.String, "email %s", email)
+	}
+
+	for _, email := range []string{
+		"user@gmail.com", "user@example.com", "user@example.ai", "user@example.co.uk",
+		"user@company.io", "not-an-email", "@gmial.com", "user@abc.com",
+		// real providers under other suffixes than the known domains
+		"user@yahoo.co.jp", "user@yahoo.com.au", "user@yahoo.co.in", "user@outlook.es",
+		"user@outlook.it", "user@outlook.jp", "user@hotmail.nl", "user@hotmail.rs",
+		// real domains with short names, close to known ones
+		"user@ge.com", "user@gmc.com", "user@bol.com", "user@lime.com", "user@mc.com",
+		"user@gmai.com",
+	} {
+		assert.Falsef(t, SuggestEmail(email).Valid, "unexpected suggestion for %s", email)
+	}
+}
+
+func TestTypoSuggesterCustomDomains(t *testing.T) {
+	suggester := NewTypoSuggester()
+	assert.False(t, suggester.SuggestEmail("user@zerobounse.net").Valid)
+
+	error_ := suggester.LoadDomains(strings.NewReader("# company domains\nzerobounce.net\n\n"))
+	assert.Nil(t, error_)
+	assert.Equal(t, "user@zerobounce.net", suggester.SuggestEmail("user@zerobounse.net").String)
+	assert.False(t, suggester.SuggestEmail("user@zerobounce.net").Valid)
+
+	// the default suggester is left untouched
+	assert.False(t, SuggestEmail("user@zerobounse.net").Valid)
+
+	suggester.MaxDistance = 0
+	assert.False(t, suggester.SuggestEmail("user@gmial.com").Valid)
+
+	suggester.MaxDistance = TYPO_DEFAULT_MAX_DISTANCE
+	suggester.MinNameLength = 3
+	assert.Equal(t, "user@aol.com", suggester.SuggestEmail("user@bol.com").String)
+	// the top level domain of short domains is still corrected
+	assert.Equal(t, "user@ge.com", SuggestEmail("user@ge.cmo").String)
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("gmail.com", "gmail.com", 5))
+	assert.Equal(t, 1, editDistance("gmial.com", "gmail.com", 5))
+	assert.Equal(t, 1, editDistance("gmai.com", "gmail.com", 5))
+	assert.Equal(t, 2, editDistance("gmaill.con", "gmail.com", 5))
+	assert.Equal(t, 3, editDistance("abc", "", 5))
+	// capped at the limit
+	assert.Equal(t, 2, editDistance("completely", "different", 2))
+}
+
+func TestValidateWithTypoPrescreen(t *testing.T) {
+	Initialize("mock_key")
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	mockValidateRequest()
+	TYPO_PRESCREEN = true
+	defer func() { TYPO_PRESCREEN = false }()
+
+	for _, email := range []string{"user@gmial.com", "user@ge.com"} {
+		MOCK_VALIDATE_RESPONSE[email] = MOCK_VALIDATE_RESPONSE["valid@example.com"]
+		defer delete(MOCK_VALIDATE_RESPONSE, email)
+	}
+
+	// the address is still validated, the suggestion being attached
+	response, error_ := Validate("user@gmial.com", SANDBOX_IP)
+	assert.Nil(t, error_)
+	assert.Equal(t, S_VALID, response.Status)
+	assert.Equal(t, "user@gmail.com", response.DidYouMean.String)
+	assert.Equal(t, "user@gmial.com", response.SubmittedAddress)
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+
+	// real short domains are not flagged
+	response, error_ = Validate("user@ge.com", SANDBOX_IP)
+	assert.Nil(t, error_)
+	assert.Equal(t, S_VALID, response.Status)
+	assert.False(t, response.DidYouMean.Valid)
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+}